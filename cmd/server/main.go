@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/leonardcser/nvim-lsp-mcp/internal/config"
 	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
 	tools "github.com/leonardcser/nvim-lsp-mcp/internal/tools"
 )
 
@@ -17,18 +23,31 @@ func main() {
 	}
 	defer logger.Close()
 
+	once := flag.Bool("once", false, "collect diagnostics once, print them and exit instead of serving MCP")
+	workspace := flag.String("workspace", os.Getenv("NVIM_LSP_MCP_DEFAULT_WORKSPACE"), "workspace for -once (defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE)")
+	format := flag.String("format", nvim.OutputText, "output format for -once: "+strings.Join(nvim.OutputFormats, ", "))
+	flag.Parse()
+
 	logger.Infof("Starting Neovim LSP MCP server")
+	loadConfig()
+
+	if *once {
+		code := runOnce(*workspace, *format)
+		logger.Close()
+		os.Exit(code)
+	}
 
 	s := server.NewMCPServer(
 		"Neovim LSP MCP",
 		"0.1.0",
 		server.WithRecovery(),
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
 	)
 	logger.Infof("Created MCP server instance")
 
 	toolReadLints := mcp.NewTool("read-lints",
-		mcp.WithDescription(multiline(
+		mcp.WithDescription(readLintsDescription(multiline(
 			"Reads linter diagnostics from the current workspace via Neovim LSP",
 			"\nFunctionality:",
 			"- Uses Neovim to query LSP diagnostics",
@@ -39,13 +58,227 @@ func main() {
 			"- If lint warnings/errors appear from files you did not create/edit, ask the user if they want you to fix those files at the end of the tasks you were given.",
 			"- If you fixed a lint error and recheck with the read-lints tool and get the same error, tell the user to reload the file in their nvim client.",
 			"- When the user asks to run lint checks or run lint tool, do not use this tool. However if the user asks to fix the lint errors, or fix the lint errors in a file, then use this tool.",
-		)),
+		))),
 		// Structured input schema using Go struct (see mcp-go docs): https://mcp-go.dev/servers/tools
 		mcp.WithInputSchema[tools.ReadLintsArgs](),
 	)
 	s.AddTool(toolReadLints, tools.ReadLintsHandler)
 	logger.Infof("Registered read-lints tool")
 
+	toolLintDiff := mcp.NewTool("lint-diff",
+		mcp.WithDescription(multiline(
+			"Reports only the linter diagnostics introduced since a git ref, via Neovim LSP",
+			"\nFunctionality:",
+			"- Checks out the base ref into a temporary git worktree (the working copy is not touched)",
+			"- Diagnoses files changed since the base ref in both the worktree and the working copy",
+			"- Returns diagnostics present in the working copy but not at the base ref",
+			"\nUsage notes:",
+			"- Use this when reviewing a branch to focus on problems the branch introduced.",
+			"- This is slower than read-lints since every changed file is diagnosed twice.",
+		)),
+		mcp.WithInputSchema[tools.LintDiffArgs](),
+	)
+	s.AddTool(toolLintDiff, tools.LintDiffHandler)
+	logger.Infof("Registered lint-diff tool")
+
+	toolRefreshLints := mcp.NewTool("refresh-lints",
+		mcp.WithDescription(multiline(
+			"Reloads files in the workspace's Neovim session so LSP servers re-check them, without waiting for results",
+			"\nFunctionality:",
+			"- Reloads the given files (or the changed files per git diff) from disk and sends didSave to LSP clients",
+			"- Returns the files refreshed immediately, skipping the settle delay and collection of read-lints",
+			"\nUsage notes:",
+			"- Call this right after editing files, then call read-lints later once the servers have caught up.",
+		)),
+		mcp.WithInputSchema[tools.RefreshLintsArgs](),
+	)
+	s.AddTool(toolRefreshLints, tools.RefreshLintsHandler)
+	logger.Infof("Registered refresh-lints tool")
+
+	toolLintFixVerify := mcp.NewTool("lint-fix-verify",
+		mcp.WithDescription(multiline(
+			"Applies the available LSP fixes to files and re-checks them, returning what was fixed and what remains",
+			"\nFunctionality:",
+			"- Collects diagnostics, then applies source.fixAll per file or the unambiguous quickfix per diagnostic",
+			"- Writes the fixed buffers and re-collects, for up to 3 rounds or until nothing more is fixed",
+			"- Returns the applied fixes and the remaining diagnostics",
+			"\nUsage notes:",
+			"- Use this as a single \"clean this up\" step, then fix the remaining diagnostics by hand.",
+			"- Buffers with unsaved changes are never edited.",
+		)),
+		mcp.WithInputSchema[tools.LintFixVerifyArgs](),
+	)
+	s.AddTool(toolLintFixVerify, tools.LintFixVerifyHandler)
+	logger.Infof("Registered lint-fix-verify tool")
+
+	toolCursorDiagnostics := mcp.NewTool("cursor-diagnostics",
+		mcp.WithDescription(multiline(
+			"Returns the diagnostics under the user's cursor, or within their visual selection, in Neovim",
+			"\nFunctionality:",
+			"- Reads the current window's cursor (or active visual selection) in the workspace's Neovim session",
+			"- Returns the diagnostics of that buffer overlapping it, as they currently are",
+			"\nUsage notes:",
+			"- Use this when the user asks \"what's wrong here\" without giving a file or position.",
+		)),
+		mcp.WithInputSchema[tools.CursorDiagnosticsArgs](),
+	)
+	s.AddTool(toolCursorDiagnostics, tools.CursorDiagnosticsHandler)
+	logger.Infof("Registered cursor-diagnostics tool")
+
+	toolLspCapabilities := mcp.NewTool("lsp-capabilities",
+		mcp.WithDescription(multiline(
+			"Lists the LSP clients active in the workspace's Neovim session and what they support",
+			"\nFunctionality:",
+			"- Returns each client's name, id, root directory and number of attached buffers",
+			"- Returns the LSP methods each server supports, from its negotiated capabilities",
+			"\nUsage notes:",
+			"- Use this to validate the setup, or to check that a server supports a method before relying on it.",
+		)),
+		mcp.WithInputSchema[tools.LspCapabilitiesArgs](),
+	)
+	s.AddTool(toolLspCapabilities, tools.LspCapabilitiesHandler)
+	logger.Infof("Registered lsp-capabilities tool")
+
+	toolLspMessages := mcp.NewTool("lsp-messages",
+		mcp.WithDescription(multiline(
+			"Shows the recent log and status messages LSP servers sent to the workspace's Neovim session",
+			"\nFunctionality:",
+			"- Returns the window/logMessage and window/showMessage notifications captured since the first lsp-messages call on the session, oldest first",
+			"- Optionally appends the tail of Neovim's LSP log file",
+			"- stop removes the capture and restores the session's original handlers",
+			"\nUsage notes:",
+			"- Use this when a server produces no diagnostics, to spot errors such as \"project not found\" or a missing dependency.",
+		)),
+		mcp.WithInputSchema[tools.LspMessagesArgs](),
+	)
+	s.AddTool(toolLspMessages, tools.LspMessagesHandler)
+	logger.Infof("Registered lsp-messages tool")
+
+	toolDoctor := mcp.NewTool("doctor",
+		mcp.WithDescription(multiline(
+			"Checks the nvim-lsp-mcp setup for a workspace and reports what is broken",
+			"\nFunctionality:",
+			"- Checks the environment, git, socket discovery and the matching Neovim session",
+			"- Checks the Neovim version, running LSP clients and a diagnostics round-trip",
+			"- Returns a PASS/FAIL checklist with a remediation hint for each failure",
+			"\nUsage notes:",
+			"- Use this first when other tools fail to attach or return nothing.",
+		)),
+		mcp.WithInputSchema[tools.DoctorArgs](),
+	)
+	s.AddTool(toolDoctor, tools.DoctorHandler)
+	logger.Infof("Registered doctor tool")
+
+	toolLspHover := mcp.NewTool("lsp-hover",
+		mcp.WithDescription(multiline(
+			"Shows LSP hover documentation (types, signatures, docs) at a position in a file",
+			"\nFunctionality:",
+			"- Loads the file into the workspace's Neovim session if needed",
+			"- Queries textDocument/hover on every attached LSP client",
+			"\nUsage notes:",
+			"- Address the position with 1-based line/col, or with a 0-based byte offset into the file.",
+		)),
+		mcp.WithInputSchema[tools.LspHoverArgs](),
+	)
+	s.AddTool(toolLspHover, tools.LspHoverHandler)
+	logger.Infof("Registered lsp-hover tool")
+
+	toolLspSymbol := mcp.NewTool("lsp-symbol",
+		mcp.WithDescription(multiline(
+			"Shows the hover documentation and definition of a symbol in a file, looked up by name instead of position",
+			"\nFunctionality:",
+			"- Finds the symbol among the file's LSP document symbols, or else its first whole-word occurrence in the text",
+			"- Queries textDocument/hover and textDocument/definition at it",
+			"\nUsage notes:",
+			"- Fails listing the candidates when several symbols share the name; use lsp-hover with one of their positions then.",
+		)),
+		mcp.WithInputSchema[tools.LspSymbolArgs](),
+	)
+	s.AddTool(toolLspSymbol, tools.LspSymbolHandler)
+	logger.Infof("Registered lsp-symbol tool")
+
+	toolLspCodeActions := mcp.NewTool("lsp-code-actions",
+		mcp.WithDescription(multiline(
+			"Lists or applies the LSP code actions (quick fixes, refactorings) at a position in a file",
+			"\nFunctionality:",
+			"- Without apply, returns the numbered list of available code actions",
+			"- With apply, applies that action, writes the changed files and returns a unified diff of the changes",
+			"\nUsage notes:",
+			"- List first, then apply by index or by title (applyTitle, stable across runs). Pass kind=source.fixAll to fix all auto-fixable problems in the file.",
+			"- Review the returned diff to explain exactly what was changed.",
+		)),
+		mcp.WithInputSchema[tools.LspCodeActionsArgs](),
+	)
+	s.AddTool(toolLspCodeActions, tools.LspCodeActionsHandler)
+	logger.Infof("Registered lsp-code-actions tool")
+
+	toolLspCodeLens := mcp.NewTool("lsp-codelens",
+		mcp.WithDescription(multiline(
+			"Lists the LSP code lenses of a file (e.g. \"run test\" or \"N references\" annotations), or executes one",
+			"\nFunctionality:",
+			"- Queries textDocument/codeLens on every attached LSP client, resolving lenses that need it",
+			"- Returns the numbered lenses with their range, command title and client",
+			"- With execute and confirm, runs the selected lens's command",
+			"\nUsage notes:",
+			"- List first, then execute by index. Commands may have side effects such as running tests.",
+		)),
+		mcp.WithInputSchema[tools.LspCodeLensArgs](),
+	)
+	s.AddTool(toolLspCodeLens, tools.LspCodeLensHandler)
+	logger.Infof("Registered lsp-codelens tool")
+
+	toolLspRenamePreview := mcp.NewTool("lsp-rename-preview",
+		mcp.WithDescription(multiline(
+			"Previews an LSP rename of the symbol at a position in a file without applying it",
+			"\nFunctionality:",
+			"- Computes the rename's WorkspaceEdit via textDocument/rename, checking with textDocument/prepareRename first",
+			"- Returns the files it would edit with their edit counts, and file renames or deletions",
+			"- With diff, also returns a unified diff of the changes; nothing is written to disk",
+			"\nUsage notes:",
+			"- Use it to assess how far a rename reaches before performing it.",
+		)),
+		mcp.WithInputSchema[tools.LspRenamePreviewArgs](),
+	)
+	s.AddTool(toolLspRenamePreview, tools.LspRenamePreviewHandler)
+	logger.Infof("Registered lsp-rename-preview tool")
+
+	toolLspContext := mcp.NewTool("lsp-context",
+		mcp.WithDescription(multiline(
+			"Shows the source lines around a position in a file, with line numbers",
+			"\nFunctionality:",
+			"- Reads the lines from the file's Neovim buffer, loading it if needed",
+			"- Marks the line of the position with >",
+			"\nUsage notes:",
+			"- Use this to inspect the code around a diagnostic instead of reading the whole file.",
+		)),
+		mcp.WithInputSchema[tools.LspContextArgs](),
+	)
+	s.AddTool(toolLspContext, tools.LspContextHandler)
+	logger.Infof("Registered lsp-context tool")
+
+	if nvim.LspRequestEnabled() {
+		toolLspRequest := mcp.NewTool("lsp-request",
+			mcp.WithDescription(multiline(
+				"Sends an arbitrary LSP request for a file and returns the raw JSON responses",
+				"\nFunctionality:",
+				"- Sends the method to every LSP client attached to the file's buffer, loading it if needed",
+				"- Fills in textDocument, and position when line/col or offset is given",
+				"\nUsage notes:",
+				"- Use this only for methods no other tool covers, e.g. textDocument/documentLink or textDocument/codeLens.",
+			)),
+			mcp.WithInputSchema[tools.LspRequestArgs](),
+		)
+		s.AddTool(toolLspRequest, tools.LspRequestHandler)
+		logger.Infof("Registered lsp-request tool")
+	}
+
+	templateDiagnostics := mcp.NewResourceTemplate(tools.DiagnosticsResourceTemplate, "Workspace diagnostics",
+		mcp.WithTemplateDescription("LSP diagnostics of the workspace's Neovim session, as the JSON output of read-lints"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(templateDiagnostics, tools.DiagnosticsResourceHandler)
+	logger.Infof("Registered diagnostics resource template")
+
 	logger.Infof("Starting MCP server on stdio")
 	if err := server.ServeStdio(s); err != nil {
 		logger.Errorf("server error: %v", err)
@@ -55,5 +288,103 @@ func main() {
 // multiline joins lines with newlines for tool descriptions.
 func multiline(lines ...string) string { return strings.Join(lines, "\n") }
 
-// placeholder to avoid unused import during boilerplate stage
-var _ = os.Environ
+// Environment variables to override the read-lints tool description.
+const (
+	envReadLintsDescription     = "NVIM_LSP_MCP_READ_LINTS_DESCRIPTION"
+	envReadLintsDescriptionFile = "NVIM_LSP_MCP_READ_LINTS_DESCRIPTION_FILE"
+)
+
+// readLintsDescription returns the read-lints description from the environment,
+// preferring the inline text over the file, and falls back to the default.
+func readLintsDescription(fallback string) string {
+	if desc := strings.TrimSpace(os.Getenv(envReadLintsDescription)); desc != "" {
+		logger.Infof("Using read-lints description from %s", envReadLintsDescription)
+		return desc
+	}
+	if path := os.Getenv(envReadLintsDescriptionFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Warnf("failed to read %s=%s, using default description: %v", envReadLintsDescriptionFile, path, err)
+			return fallback
+		}
+		if desc := strings.TrimSpace(string(data)); desc != "" {
+			logger.Infof("Using read-lints description from %s", path)
+			return desc
+		}
+		logger.Warnf("%s=%s is empty, using default description", envReadLintsDescriptionFile, path)
+	}
+	return fallback
+}
+
+// loadConfig applies the defaults from the config file, if any. An invalid
+// config is logged and ignored so the server still starts.
+func loadConfig() {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		logger.Errorf("ignoring config: %v", err)
+		return
+	}
+	if cfg.Source == "" {
+		return
+	}
+	if cfg.LogLevel != "" {
+		if err := logger.SetLevel(cfg.LogLevel); err != nil {
+			logger.Errorf("ignoring config logLevel: %v", err)
+		}
+	}
+	if settle, _ := cfg.Settle(); settle > 0 {
+		nvim.SettleDelay = settle
+	}
+	if cfg.MaxReload > 0 {
+		nvim.MaxFilesToReload = cfg.MaxReload
+	}
+	nvim.LenientDiscovery = cfg.LenientDiscovery
+	tools.SetReadLintsDefaults(cfg.Severities, cfg.OutputFormat)
+	logger.Infof("Loaded config from %s: %+v", cfg.Source, *cfg)
+}
+
+// runOnce collects the workspace diagnostics, prints them to stdout and returns
+// the exit code: 0 if no errors were found, 1 if some were and 2 if the
+// collection failed.
+func runOnce(workspace, format string) int {
+	if workspace == "" {
+		fmt.Fprintln(os.Stderr, "nvim-lsp-mcp: -once requires -workspace")
+		return 2
+	}
+	if !slices.Contains(nvim.OutputFormats, format) {
+		fmt.Fprintf(os.Stderr, "nvim-lsp-mcp: invalid -format %q\n", format)
+		return 2
+	}
+	ctx := context.Background()
+	cli, err := nvim.ConnectWorkspace(ctx, workspace)
+	if err != nil && nvim.HeadlessEnabled() {
+		cli, err = nvim.StartHeadless(ctx, workspace)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nvim-lsp-mcp: failed to attach to Neovim: %v\n", err)
+		return 2
+	}
+	defer cli.Close()
+	if err := nvim.CheckVersion(ctx, cli); err != nil {
+		fmt.Fprintf(os.Stderr, "nvim-lsp-mcp: %v\n", err)
+		return 2
+	}
+
+	report, err := nvim.GatherDiagnostics(ctx, cli, nvim.CollectOptions{FailOnErrors: true})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nvim-lsp-mcp: failed to collect diagnostics: %v\n", err)
+		return 2
+	}
+	output, err := nvim.Format(report, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nvim-lsp-mcp: failed to format diagnostics: %v\n", err)
+		return 2
+	}
+	if output != "" {
+		fmt.Println(output)
+	}
+	if report.Gate != nil && report.Gate.Failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// Environment variable naming the config file, overriding the default path.
+const envConfigPath = "NVIM_LSP_MCP_CONFIG"
+
+// Config holds server-wide defaults. Zero values keep the built-in defaults,
+// and per-call arguments override the read-lints defaults.
+type Config struct {
+	// SettleTime is how long to wait for LSP servers after a refresh, as a
+	// Go duration such as "5s".
+	SettleTime string `json:"settleTime,omitempty"`
+	// MaxReload caps the number of files reloaded per call.
+	MaxReload int `json:"maxReload,omitempty"`
+	// LogLevel is info, warn or error.
+	LogLevel string `json:"logLevel,omitempty"`
+	// Severities is the default read-lints severities filter.
+	Severities []string `json:"severities,omitempty"`
+	// OutputFormat is the default read-lints output format.
+	OutputFormat string `json:"outputFormat,omitempty"`
+	// LenientDiscovery lets discovery fall back to a session whose cwd
+	// contains the workspace or lies within it.
+	LenientDiscovery bool `json:"lenientDiscovery,omitempty"`
+
+	// Source is the path the config was read from, empty if no file exists.
+	Source string `json:"-"`
+}
+
+// Settle returns the parsed SettleTime, or 0 if unset.
+func (c *Config) Settle() (time.Duration, error) {
+	if c.SettleTime == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.SettleTime)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid settleTime %q: want a duration such as 3s", c.SettleTime)
+	}
+	return d, nil
+}
+
+// Path returns the config file path: NVIM_LSP_MCP_CONFIG, or
+// nvim-lsp-mcp/config.json under $XDG_CONFIG_HOME (default ~/.config).
+func Path() string {
+	if path := os.Getenv(envConfigPath); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "nvim-lsp-mcp", "config.json")
+}
+
+// Load reads the config file at path. A missing file at the default path
+// yields an empty config; a missing file named by NVIM_LSP_MCP_CONFIG is an
+// error.
+func Load(path string) (*Config, error) {
+	var cfg Config
+	if path == "" {
+		return &cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && os.Getenv(envConfigPath) == "" {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if cfg.MaxReload < 0 {
+		return nil, fmt.Errorf("invalid config %s: maxReload must not be negative", path)
+	}
+	if _, err := cfg.Settle(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	for _, sev := range cfg.Severities {
+		if !slices.Contains([]string{"error", "warning", "info", "hint"}, sev) {
+			return nil, fmt.Errorf("invalid config %s: invalid severity %q", path, sev)
+		}
+	}
+	if cfg.OutputFormat != "" && !slices.Contains(nvim.OutputFormats, cfg.OutputFormat) {
+		return nil, fmt.Errorf("invalid config %s: invalid outputFormat %q", path, cfg.OutputFormat)
+	}
+	cfg.Source = path
+	return &cfg, nil
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Environment variable to configure log file path.
@@ -14,8 +15,22 @@ var (
 	std           *log.Logger
 	logFile       *os.File
 	isInitialized bool
+	minLevel      = levels["INFO"]
 )
 
+// levels orders the log levels by severity.
+var levels = map[string]int{"INFO": 0, "WARN": 1, "ERROR": 2}
+
+// SetLevel drops messages below level: info, warn or error.
+func SetLevel(level string) error {
+	rank, ok := levels[strings.ToUpper(level)]
+	if !ok {
+		return fmt.Errorf("invalid log level %q: want info, warn or error", level)
+	}
+	minLevel = rank
+	return nil
+}
+
 // InitFromEnv initializes the logger using NVIM_LSP_MCP_LOG or a default path.
 func InitFromEnv() error {
 	path := os.Getenv(envLogPath)
@@ -74,6 +89,9 @@ func Warnf(format string, args ...any) { write("WARN", format, args...) }
 func Errorf(format string, args ...any) { write("ERROR", format, args...) }
 
 func write(level string, format string, args ...any) {
+	if levels[level] < minLevel {
+		return
+	}
 	if std == nil {
 		// Fallback: initialize with default if not already.
 		_ = InitFromEnv()
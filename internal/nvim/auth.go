@@ -0,0 +1,100 @@
+package nvim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// envRPCToken holds the token for sockets behind an authenticating proxy.
+const envRPCToken = "NVIM_LSP_MCP_RPC_TOKEN"
+
+// HandshakeTimeout bounds the auth handshake and the TCP reachability probe.
+const HandshakeTimeout = 5 * time.Second
+
+// dial connects to the Neovim socket at addr. When NVIM_LSP_MCP_RPC_TOKEN is
+// set, the proxy in front of the socket is first sent "AUTH <token>\n" and
+// must answer "OK\n" before msgpack-RPC starts. go-client has no notion of
+// auth, so the handshake happens in the net dial hook.
+func dial(addr string) (*nv.Nvim, error) {
+	token := os.Getenv(envRPCToken)
+	if token == "" {
+		n, err := nv.Dial(addr)
+		if err != nil {
+			return nil, err
+		}
+		// A proxy requiring auth accepts the TCP connection but never
+		// answers, so check the session responds before handing it out
+		if isTCPAddress(addr) {
+			if err := probe(n); err != nil {
+				_ = n.Close()
+				return nil, fmt.Errorf("%s did not answer (%w); if it requires authentication, set %s", addr, err, envRPCToken)
+			}
+		}
+		return n, nil
+	}
+	return nv.Dial(addr, nv.DialNetDial(func(ctx context.Context, network, address string) (net.Conn, error) {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		if err := handshake(conn, token); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("auth handshake with %s: %w", address, err)
+		}
+		return conn, nil
+	}))
+}
+
+// handshake authenticates conn with token.
+func handshake(conn net.Conn, token string) error {
+	if err := conn.SetDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "AUTH %s\n", token); err != nil {
+		return err
+	}
+	// Read byte by byte so no RPC data is consumed past the reply
+	var reply []byte
+	buf := make([]byte, 1)
+	for len(reply) < 256 {
+		if _, err := conn.Read(buf); err != nil {
+			return err
+		}
+		if buf[0] == '\n' {
+			break
+		}
+		reply = append(reply, buf[0])
+	}
+	if strings.TrimSpace(string(reply)) != "OK" {
+		return errors.New("token rejected")
+	}
+	return conn.SetDeadline(time.Time{})
+}
+
+// probe checks that n answers a trivial request within HandshakeTimeout.
+func probe(n *nv.Nvim) error {
+	done := make(chan error, 1)
+	go func() {
+		var v int
+		done <- n.Eval("1", &v)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(HandshakeTimeout):
+		return errors.New("timed out")
+	}
+}
+
+// isTCPAddress reports whether addr is dialed over TCP, as nv.Dial decides.
+func isTCPAddress(addr string) bool {
+	return strings.Contains(addr, ":")
+}
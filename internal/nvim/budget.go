@@ -0,0 +1,79 @@
+package nvim
+
+import (
+	"cmp"
+	"slices"
+)
+
+// OmittedGroup counts diagnostics of one file and severity left out of a
+// report, e.g. to fit a budget.
+type OmittedGroup struct {
+	File     string `json:"file"`
+	Severity string `json:"severity"`
+	Count    int    `json:"count"`
+}
+
+// severityRank orders severities from most to least important.
+func severityRank(severity string) int {
+	switch severity {
+	case "error":
+		return 1
+	case "warning":
+		return 2
+	case "info":
+		return 3
+	case "hint":
+		return 4
+	default:
+		return 5
+	}
+}
+
+// sortBySeverity sorts diagnostics most severe first, then by position.
+func sortBySeverity(diags []Diagnostic) {
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(severityRank(a.Severity), severityRank(b.Severity)),
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Col, b.Col),
+		)
+	})
+}
+
+// trimDiagnostics keeps the most severe diagnostics, at most limit of them and
+// whose text lines fit within budget characters (each bound ignored when not
+// positive), and summarizes the rest by file and severity. Selection stops at
+// the first diagnostic that does not fit, so a less severe diagnostic is never
+// kept in place of a more severe one.
+func trimDiagnostics(diags []Diagnostic, limit, budget int) ([]Diagnostic, []OmittedGroup) {
+	sortBySeverity(diags)
+
+	used := 0
+	kept := len(diags)
+	for i, d := range diags {
+		// Each line is followed by a newline
+		size := len(d.String()) + 1
+		if (limit > 0 && i >= limit) || (budget > 0 && used+size > budget) {
+			kept = i
+			break
+		}
+		used += size
+	}
+	if kept == len(diags) {
+		return diags, nil
+	}
+
+	var omitted []OmittedGroup
+	index := make(map[[2]string]int)
+	for _, d := range diags[kept:] {
+		key := [2]string{d.File, d.Severity}
+		if i, ok := index[key]; ok {
+			omitted[i].Count++
+			continue
+		}
+		index[key] = len(omitted)
+		omitted = append(omitted, OmittedGroup{File: d.File, Severity: d.Severity, Count: 1})
+	}
+	return diags[:kept], omitted
+}
@@ -0,0 +1,78 @@
+package nvim
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTrimDiagnostics(t *testing.T) {
+	diags := func() []Diagnostic {
+		return []Diagnostic{
+			{File: "/b.go", Line: 2, Col: 1, Severity: "warning", Message: "w1"},
+			{File: "/a.go", Line: 1, Col: 1, Severity: "error", Message: "e1"},
+			{File: "/b.go", Line: 5, Col: 1, Severity: "hint", Message: "h1"},
+			{File: "/a.go", Line: 3, Col: 1, Severity: "error", Message: "e2"},
+			{File: "/b.go", Line: 9, Col: 1, Severity: "warning", Message: "w2"},
+		}
+	}
+	// The budget counts each rendered line with its newline
+	size := make(map[string]int)
+	for _, d := range diags() {
+		size[d.Message] = len(d.String()) + 1
+	}
+	bothErrors := size["e1"] + size["e2"]
+
+	tests := []struct {
+		name        string
+		limit       int
+		budget      int
+		wantKept    []string
+		wantOmitted []OmittedGroup
+	}{
+		{"no bounds", 0, 0, []string{"e1", "e2", "w1", "w2", "h1"}, nil},
+		{"limit above count", 10, 0, []string{"e1", "e2", "w1", "w2", "h1"}, nil},
+		{"limit equal to count", 5, 0, []string{"e1", "e2", "w1", "w2", "h1"}, nil},
+		{"limit keeps most severe", 3, 0, []string{"e1", "e2", "w1"}, []OmittedGroup{
+			{File: "/b.go", Severity: "warning", Count: 1},
+			{File: "/b.go", Severity: "hint", Count: 1},
+		}},
+		{"budget exactly fits the errors", 0, bothErrors, []string{"e1", "e2"}, []OmittedGroup{
+			{File: "/b.go", Severity: "warning", Count: 2},
+			{File: "/b.go", Severity: "hint", Count: 1},
+		}},
+		{"budget one short of the errors", 0, bothErrors - 1, []string{"e1"}, []OmittedGroup{
+			{File: "/a.go", Severity: "error", Count: 1},
+			{File: "/b.go", Severity: "warning", Count: 2},
+			{File: "/b.go", Severity: "hint", Count: 1},
+		}},
+		{"budget below one line", 0, size["e1"] - 1, nil, []OmittedGroup{
+			{File: "/a.go", Severity: "error", Count: 2},
+			{File: "/b.go", Severity: "warning", Count: 2},
+			{File: "/b.go", Severity: "hint", Count: 1},
+		}},
+		{"tighter of limit and budget", 1, bothErrors, []string{"e1"}, []OmittedGroup{
+			{File: "/a.go", Severity: "error", Count: 1},
+			{File: "/b.go", Severity: "warning", Count: 2},
+			{File: "/b.go", Severity: "hint", Count: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, omitted := trimDiagnostics(diags(), tt.limit, tt.budget)
+			var messages []string
+			for _, d := range kept {
+				messages = append(messages, d.Message)
+			}
+			if !slices.Equal(messages, tt.wantKept) {
+				t.Errorf("kept %v, want %v", messages, tt.wantKept)
+			}
+			if !slices.Equal(omitted, tt.wantOmitted) {
+				t.Errorf("omitted %v, want %v", omitted, tt.wantOmitted)
+			}
+		})
+	}
+
+	if kept, omitted := trimDiagnostics(nil, 1, 1); len(kept) != 0 || omitted != nil {
+		t.Errorf("trimDiagnostics(nil) = %v, %v, want nothing", kept, omitted)
+	}
+}
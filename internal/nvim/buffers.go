@@ -0,0 +1,212 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// Buffer scopes limiting which buffers diagnostics are collected from.
+const (
+	// ScopeAll collects from every buffer in the session
+	ScopeAll = "all"
+	// ScopeTab collects from buffers shown in the windows of the current tabpage
+	ScopeTab = "tab"
+	// ScopeWindow collects from the buffer of the current window
+	ScopeWindow = "window"
+)
+
+// Buffer sources selecting which kinds of buffers diagnostics are collected
+// from, applied on top of the scope.
+const (
+	// BufferSourceAll keeps every buffer, including unlisted ones such as
+	// those loaded by a refresh
+	BufferSourceAll = "all"
+	// BufferSourceListed keeps the buffers listed in :ls
+	BufferSourceListed = "listed"
+	// BufferSourceLoaded keeps the buffers loaded into memory
+	BufferSourceLoaded = "loaded"
+	// BufferSourceArgs keeps the buffers of the files in the argument list
+	BufferSourceArgs = "args"
+)
+
+// BufferSources lists the supported buffer sources.
+var BufferSources = []string{BufferSourceAll, BufferSourceListed, BufferSourceLoaded, BufferSourceArgs}
+
+//go:embed lua/buffer_source.lua
+var bufferSourceLua string
+
+// sourceBuffers returns the buffers in scope that match source.
+func sourceBuffers(ctx context.Context, c *Client, scope, source string) ([]int, error) {
+	bufs, err := listBuffers(ctx, c, scope)
+	if err != nil || source == "" || source == BufferSourceAll || len(bufs) == 0 {
+		return bufs, err
+	}
+	kept, err := callResult(ctx, c, func(n *nv.Nvim, out *[]int) error { return n.ExecLua(bufferSourceLua, out, bufs, source) })
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("nvim: buffer source %s kept %d of %d buffers", source, len(kept), len(bufs))
+	return kept, nil
+}
+
+// listBuffers returns the buffers to collect diagnostics from for scope.
+// Narrowed scopes fall back to all buffers when they yield nothing.
+func listBuffers(ctx context.Context, c *Client, scope string) ([]int, error) {
+	var bufs []int
+	switch scope {
+	case ScopeTab:
+		wins, err := callResult(ctx, c, func(n *nv.Nvim, out *[]nv.Window) error {
+			tab, err := n.CurrentTabpage()
+			if err != nil {
+				return err
+			}
+			*out, err = n.TabpageWindows(tab)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, win := range wins {
+			buf, err := callResult(ctx, c, func(n *nv.Nvim, out *nv.Buffer) error {
+				var err error
+				*out, err = n.WindowBuffer(win)
+				return err
+			})
+			if err != nil {
+				logger.Errorf("nvim: nvim_win_get_buf(%d) error: %v", win, err)
+				continue
+			}
+			if !slices.Contains(bufs, int(buf)) {
+				bufs = append(bufs, int(buf))
+			}
+		}
+	case ScopeWindow:
+		buf, err := callResult(ctx, c, func(n *nv.Nvim, out *nv.Buffer) error {
+			var err error
+			*out, err = n.CurrentBuffer()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		bufs = append(bufs, int(buf))
+	}
+	if len(bufs) > 0 {
+		logger.Infof("nvim: scope=%s buffers=%d", scope, len(bufs))
+		return bufs, nil
+	}
+	if scope != "" && scope != ScopeAll {
+		c.warnf("scope %s yielded no buffers, falling back to %s", scope, ScopeAll)
+	}
+
+	return callResult(ctx, c, func(n *nv.Nvim, out *[]int) error { return n.Call("nvim_list_bufs", out) })
+}
+
+// PluginBufferPrefixes are buffer name prefixes of plugin buffers that do not
+// name a file, beyond any name with a URI scheme other than file://.
+var PluginBufferPrefixes = []string{"NvimTree_", "neo-tree ", "Trouble", "[Scratch]"}
+
+// isPluginBuffer reports whether the buffer name is not a filesystem path,
+// such as oil:// or fugitive:// URIs, or starts with one of the given extra
+// prefixes or PluginBufferPrefixes.
+func isPluginBuffer(name string, extra []string) bool {
+	if i := strings.Index(name, "://"); i > 0 && name[:i] != "file" {
+		return true
+	}
+	hasPrefix := func(p string) bool { return p != "" && strings.HasPrefix(name, p) }
+	return slices.ContainsFunc(PluginBufferPrefixes, hasPrefix) || slices.ContainsFunc(extra, hasPrefix)
+}
+
+// hasExtension reports whether name has one of extensions, which may be given
+// with or without the leading dot.
+func hasExtension(name string, extensions []string) bool {
+	ext := filepath.Ext(name)
+	for _, want := range extensions {
+		if ext != "" && strings.EqualFold(ext, "."+strings.TrimPrefix(want, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// modifiedAfter reports whether the file named by a buffer was modified after
+// since. Files that no longer exist are never considered modified.
+func modifiedAfter(name string, since time.Time) bool {
+	info, err := os.Stat(name)
+	if err != nil {
+		logger.Infof("nvim: skipping %s for modifiedSince: %v", name, err)
+		return false
+	}
+	return info.ModTime().After(since)
+}
+
+// FileClients lists the LSP clients attached to the buffer of a requested
+// file, to tell an unsupported file type from a clean file.
+type FileClients struct {
+	File string `json:"file"`
+	// Loaded is false when the file has no buffer in the session.
+	Loaded  bool     `json:"loaded"`
+	Clients []string `json:"clients"`
+}
+
+// attachedClients returns the names of the LSP clients attached to any of
+// files, in order of first appearance.
+func attachedClients(ctx context.Context, c *Client, files []string) ([]string, error) {
+	fcs, err := fileClients(ctx, c, files)
+	if err != nil {
+		return nil, err
+	}
+	var clients []string
+	for _, fc := range fcs {
+		for _, name := range fc.Clients {
+			if !slices.Contains(clients, name) {
+				clients = append(clients, name)
+			}
+		}
+	}
+	return clients, nil
+}
+
+// fileClients resolves files to their buffers, matched like in
+// collectBufferDiagnostics, and returns the clients attached to each.
+func fileClients(ctx context.Context, c *Client, files []string) ([]FileClients, error) {
+	bufs, err := callResult(ctx, c, func(n *nv.Nvim, out *[]int) error { return n.Call("nvim_list_bufs", out) })
+	if err != nil {
+		return nil, err
+	}
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(bufferInfoLua, out, bufs) })
+	if err != nil {
+		return nil, err
+	}
+	var infos []bufferInfo
+	if err := json.Unmarshal([]byte(jsonStr), &infos); err != nil {
+		return nil, fmt.Errorf("invalid buffer info: %w", err)
+	}
+	byPath := make(map[string]bufferInfo, len(infos))
+	for _, info := range infos {
+		if info.Name != "" && !isPluginBuffer(info.Name, nil) {
+			byPath[canonicalPath(bufferPath(info.Name))] = info
+		}
+	}
+
+	result := make([]FileClients, len(files))
+	for i, file := range files {
+		info, ok := byPath[canonicalPath(file)]
+		result[i] = FileClients{File: file, Loaded: ok, Clients: info.Clients}
+		if result[i].Clients == nil {
+			result[i].Clients = []string{}
+		}
+	}
+	return result, nil
+}
@@ -11,19 +11,70 @@ import (
 // Client wraps a Neovim RPC client.
 type Client struct {
 	NV *nv.Nvim
+
+	// addr is the socket address of the session, used to share request slots
+	// between clients attached to the same Neovim.
+	addr string
+	// method records how the session was found, one of the Connect* constants.
+	method string
+
+	// workspace is the cwd the session was matched against, used to find the
+	// session again if the connection drops.
+	workspace string
+
+	// requested is the workspace a lenient discovery was asked for, when it
+	// differs from the session cwd recorded in workspace.
+	requested string
+
+	// warnings collects the operational warnings of the current collection,
+	// see warnf.
+	warnings []string
+
+	// progress receives progress updates, see SetProgress.
+	progress      ProgressFunc
+	progressCount int
 }
 
-// ConnectFromEnv attaches to an existing Neovim via NVIM_LISTEN_ADDRESS only.
+// Ways a session can be found, reported in Meta.Connection. See also
+// ConnectHeadless.
+const (
+	ConnectListenAddress = "NVIM_LISTEN_ADDRESS"
+	ConnectNvimEnv       = "$NVIM"
+	ConnectDiscovery     = "discovery"
+)
+
+// ConnectFromEnv attaches to an existing Neovim via NVIM_LISTEN_ADDRESS, or
+// via $NVIM when running inside a Neovim terminal.
 func ConnectFromEnv(ctx context.Context) (*Client, error) {
-	addr := os.Getenv("NVIM_LISTEN_ADDRESS")
+	addr, method := os.Getenv("NVIM_LISTEN_ADDRESS"), ConnectListenAddress
 	if addr == "" {
-		return nil, errors.New("NVIM_LISTEN_ADDRESS is not set")
+		addr, method = os.Getenv("NVIM"), ConnectNvimEnv
 	}
-	n, err := nv.Dial(addr)
+	if addr == "" {
+		return nil, errors.New("neither NVIM_LISTEN_ADDRESS nor NVIM is set")
+	}
+	n, err := dial(addr)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{NV: n}, nil
+	return &Client{NV: n, addr: addr, method: method}, nil
+}
+
+// Connection describes how the client reached its Neovim session.
+type Connection struct {
+	Method string `json:"method"`
+	Addr   string `json:"addr"`
+}
+
+// Connection returns how the client reached its session.
+func (c *Client) Connection() Connection {
+	return Connection{Method: c.method, Addr: c.addr}
+}
+
+// LenientMatch reports whether the session was found by lenient discovery,
+// so its cwd may differ from the requested workspace.
+func (c *Client) LenientMatch() bool {
+	return c.requested != ""
 }
 
 // Close closes the underlying Neovim client.
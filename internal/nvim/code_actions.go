@@ -0,0 +1,68 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+//go:embed lua/code_actions.lua
+var codeActionsLua string
+
+// CodeAction is a code action offered by an LSP client.
+type CodeAction struct {
+	Title  string `json:"title"`
+	Kind   string `json:"kind,omitempty"`
+	Client string `json:"client"`
+	// Preferred is set when the server marks the action as the preferred fix.
+	Preferred bool `json:"isPreferred,omitempty"`
+}
+
+// CodeActionResult is the outcome of listing or applying code actions.
+type CodeActionResult struct {
+	Actions []CodeAction `json:"actions"`
+	// Applied is the title of the applied action, empty when only listing.
+	Applied string `json:"applied"`
+	// Changes holds the content of every file the applied action changed.
+	Changes []FileChange `json:"changes"`
+	// Unsaved lists changed buffers that had unsaved user changes and were
+	// therefore not written to disk.
+	Unsaved []string `json:"unsaved"`
+}
+
+// CodeActions lists the code actions available at the position in file,
+// optionally restricted to a kind such as "quickfix" or "source.fixAll". When
+// apply is a 1-based index into the listed actions, or title selects one by
+// its exact title (or else the only title containing it, ignoring case), that
+// action is resolved if needed, applied and the buffers it changed are
+// written.
+func CodeActions(ctx context.Context, c *Client, file string, pos Position, kind string, apply int, title string) (*CodeActionResult, error) {
+	bp, err := resolvePosition(ctx, c, file, pos)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(codeActionsLua, out, bp.Bufnr, bp.Row, bp.Col, kind, apply, luaTimeout(ctx, RequestTimeout), title)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result CodeActionResult
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("invalid code action result: %w", err)
+	}
+	if result.Applied != "" {
+		logger.Infof("nvim: applied code action %q, changed %d files", result.Applied, len(result.Changes))
+	}
+	return &result, nil
+}
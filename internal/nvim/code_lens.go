@@ -0,0 +1,64 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+//go:embed lua/code_lens.lua
+var codeLensLua string
+
+// CodeLens is a code lens offered by an LSP client, with its 1-based range.
+type CodeLens struct {
+	Line    int    `json:"line"`
+	Col     int    `json:"col"`
+	EndLine int    `json:"endLine"`
+	EndCol  int    `json:"endCol"`
+	Title   string `json:"title"`
+	Command string `json:"command,omitempty"`
+	Client  string `json:"client"`
+}
+
+// CodeLensResult is the outcome of listing or executing code lenses.
+type CodeLensResult struct {
+	Lenses []CodeLens `json:"lenses"`
+	// Executed is the title of the executed lens, empty when only listing.
+	Executed string `json:"executed"`
+	// Supported is false when no attached client supports code lens.
+	Supported bool `json:"supported"`
+}
+
+// CodeLenses lists the code lenses of file, resolving those whose command the
+// server left out. When execute is a 1-based index into the listed lenses,
+// that lens's command is executed by its client.
+func CodeLenses(ctx context.Context, c *Client, file string, execute int) (*CodeLensResult, error) {
+	bp, err := resolvePosition(ctx, c, file, Position{Line: 1, Col: 1})
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(codeLensLua, out, bp.Bufnr, execute, luaTimeout(ctx, RequestTimeout))
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result CodeLensResult
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("invalid code lens result: %w", err)
+	}
+	if result.Executed != "" {
+		logger.Infof("nvim: executed code lens %q", result.Executed)
+	}
+	return &result, nil
+}
@@ -0,0 +1,33 @@
+package nvim
+
+import (
+	"context"
+	"sync"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// vimJSONSupport caches per session address whether vim.json is available.
+var vimJSONSupport sync.Map
+
+// hasVimJSON reports whether the session provides vim.json.encode. Very old
+// Neovim builds lack it. The answer is detected once per session.
+func (c *Client) hasVimJSON(ctx context.Context) bool {
+	if v, ok := vimJSONSupport.Load(c.addr); ok {
+		return v.(bool)
+	}
+	ok, err := callResult(ctx, c, func(n *nv.Nvim, out *bool) error {
+		return n.ExecLua("return vim.json ~= nil and vim.json.encode ~= nil", out)
+	})
+	if err != nil {
+		// Assume the common case without caching a transient failure
+		return true
+	}
+	if !ok {
+		logger.Warnf("nvim: vim.json is unavailable in %s, decoding diagnostics from msgpack", c.addr)
+	}
+	vimJSONSupport.Store(c.addr, ok)
+	return ok
+}
@@ -0,0 +1,59 @@
+package nvim
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+//go:embed lua/cursor_range.lua
+var cursorRangeLua string
+
+// CursorRange is the cursor position, or the visual selection, of the current
+// window, with 1-based lines and byte columns.
+type CursorRange struct {
+	Bufnr     int  `json:"bufnr"`
+	StartLine int  `json:"startLine"`
+	StartCol  int  `json:"startCol"`
+	EndLine   int  `json:"endLine"`
+	EndCol    int  `json:"endCol"`
+	Selection bool `json:"selection"`
+}
+
+// DiagnosticsAtCursor returns the diagnostics of the current buffer that
+// overlap the cursor, or the visual selection when one is active. The
+// buffer's current diagnostics are read as they are, without a refresh.
+func DiagnosticsAtCursor(ctx context.Context, c *Client) (CursorRange, []Diagnostic, error) {
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(cursorRangeLua, out) })
+	if err != nil {
+		return CursorRange{}, nil, err
+	}
+	var cr CursorRange
+	if err := json.Unmarshal([]byte(jsonStr), &cr); err != nil {
+		return CursorRange{}, nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	diags, err := collectBufferDiagnostics(ctx, c, []int{cr.Bufnr}, CollectOptions{})
+	if err != nil {
+		return cr, nil, err
+	}
+	var overlapping []Diagnostic
+	for _, d := range diags {
+		endLine, endCol := d.EndLine, d.EndCol
+		if endLine == 0 {
+			endLine, endCol = d.Line, d.Col
+		}
+		if comparePos(d.Line, d.Col, cr.EndLine, cr.EndCol) <= 0 && comparePos(cr.StartLine, cr.StartCol, endLine, endCol) <= 0 {
+			overlapping = append(overlapping, d)
+		}
+	}
+	return cr, overlapping, nil
+}
+
+// comparePos orders two line/column positions.
+func comparePos(line1, col1, line2, col2 int) int {
+	return cmp.Or(cmp.Compare(line1, line2), cmp.Compare(col1, col2))
+}
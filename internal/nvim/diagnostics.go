@@ -5,17 +5,44 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
+	"path/filepath"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	nv "github.com/neovim/go-client/nvim"
+
 	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
 )
 
-const (
+// Defaults that the config file may override.
+var (
 	// MaxFilesToReload is the maximum number of files to reload for diagnostics
 	// If the number of files exceeds this limit, reloading is disabled
 	MaxFilesToReload = 100
+
+	// SettleDelay is how long to wait after a refresh for LSP servers to
+	// publish updated diagnostics
+	SettleDelay = 3 * time.Second
+)
+
+const (
+	// RecheckDelay is how long to wait before re-polling an empty result
+	// while LSP servers are still reporting progress
+	RecheckDelay = 2 * time.Second
+
+	// MaxLuaArgBytes bounds the combined size of the file paths passed to a
+	// single refresh call; longer lists are split across calls
+	MaxLuaArgBytes = 32 * 1024
+
+	// DiagnosticChunkSize is the number of diagnostics above which a buffer's
+	// diagnostics are fetched in blocks of lines, each expected to hold about
+	// this many, to keep RPC payloads bounded
+	DiagnosticChunkSize = 2000
 )
 
 type luaFilterResult struct {
@@ -30,14 +57,56 @@ var filterLua string
 //go:embed lua/refresh_diagnostics.lua
 var refreshLua string
 
+//go:embed lua/buffer_info.lua
+var bufferInfoLua string
+
+//go:embed lua/namespace_clients.lua
+var namespaceClientsLua string
+
+//go:embed lua/lsp_busy.lua
+var lspBusyLua string
+
+//go:embed lua/diagnostics_range.lua
+var diagnosticsRangeLua string
+
+// bufferInfo is the metadata of a valid buffer returned by bufferInfoLua.
+type bufferInfo struct {
+	Bufnr    int      `json:"bufnr"`
+	Name     string   `json:"name"`
+	Filetype string   `json:"filetype"`
+	Clients  []string `json:"clients"`
+	// Lines and Diagnostics size the buffer for chunked fetching.
+	Lines       int `json:"lines"`
+	Diagnostics int `json:"diagnostics"`
+}
+
+// Shapes diagnostics are fetched from Neovim in, see CollectOptions.FetchShape.
+const (
+	// FetchShapeDiagnostic reads the items of vim.diagnostic.get
+	FetchShapeDiagnostic = "diagnostic"
+	// FetchShapeQflist reads the quickfix entries of vim.diagnostic.toqflist
+	FetchShapeQflist = "qflist"
+)
+
+// FetchShapes lists the supported fetch shapes.
+var FetchShapes = []string{FetchShapeDiagnostic, FetchShapeQflist}
+
 // fetchBufferDiagnostics tries to fetch diagnostics for a given buffer.
-// It first asks Lua for the count, then attempts to decode the table directly.
-// If decoding yields fewer items than Lua reports, it falls back to JSON encoding in Lua.
-func fetchBufferDiagnostics(c *Client, bufnr int) ([]map[string]any, error) {
+// Diagnostics are encoded with vim.json in Lua and unmarshaled in Go, or
+// decoded from msgpack on builds without vim.json, which only get this far
+// with NVIM_LSP_MCP_SKIP_VERSION_CHECK. With FetchShapeQflist
+// they are fetched as quickfix entries and mapped back (see qflistItem).
+func fetchBufferDiagnostics(ctx context.Context, c *Client, bufnr int, shape string) ([]map[string]any, error) {
+	if !c.hasVimJSON(ctx) {
+		return fetchBufferDiagnosticsMsgpack(ctx, c, bufnr)
+	}
+	if shape == FetchShapeQflist {
+		return fetchBufferQflist(ctx, c, bufnr)
+	}
 	// Encode in Lua and unmarshal in Go for stability
-	var jsonStr string
 	codeJSON := fmt.Sprintf("return vim.json.encode(vim.diagnostic.get(%d))", bufnr)
-	if err := c.NV.ExecLua(codeJSON, &jsonStr); err != nil {
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(codeJSON, out) })
+	if err != nil {
 		return nil, err
 	}
 	if jsonStr == "" || jsonStr == "null" {
@@ -50,55 +119,523 @@ func fetchBufferDiagnostics(c *Client, bufnr int) ([]map[string]any, error) {
 	return items, nil
 }
 
-// refreshWorkspaceDiagnostics forces a refresh of workspace diagnostics for specific files
-func refreshWorkspaceDiagnostics(c *Client, files []string, workspace string, maxFiles int) error {
+// fetchBufferQflist fetches the diagnostics of a buffer shaped as quickfix
+// entries by vim.diagnostic.toqflist and maps them to vim.diagnostic items.
+func fetchBufferQflist(ctx context.Context, c *Client, bufnr int) ([]map[string]any, error) {
+	code := fmt.Sprintf("local q = vim.diagnostic.toqflist(vim.diagnostic.get(%d)) if #q == 0 then return '[]' end return vim.json.encode(q)", bufnr)
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(code, out) })
+	if err != nil {
+		return nil, err
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal([]byte(jsonStr), &entries); err != nil {
+		return nil, err
+	}
+	items := make([]map[string]any, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, qflistItem(entry))
+	}
+	return items, nil
+}
+
+// qflistSeverities maps quickfix entry types to diagnostic severities.
+var qflistSeverities = map[string]float64{"E": 1, "W": 2, "I": 3, "N": 4}
+
+// qflistItem converts a quickfix entry (1-based lnum/col, text, type) into
+// the shape of a vim.diagnostic item. Quickfix entries carry no source, code
+// or namespace, so those stay unset.
+func qflistItem(entry map[string]any) map[string]any {
+	item := map[string]any{"message": entry["text"]}
+	if sev, ok := qflistSeverities[fmt.Sprint(entry["type"])]; ok {
+		item["severity"] = sev
+	}
+	for _, key := range []string{"lnum", "col", "end_lnum", "end_col"} {
+		if v, ok := entry[key].(float64); ok && v > 0 {
+			item[key] = v - 1
+		}
+	}
+	return item
+}
+
+// fetchBufferDiagnosticsMsgpack fetches the diagnostics of a buffer as a Lua
+// table decoded by go-client, re-encoded through JSON so values have the same
+// types as on the vim.json path.
+func fetchBufferDiagnosticsMsgpack(ctx context.Context, c *Client, bufnr int) ([]map[string]any, error) {
+	// An empty table would decode as a map
+	code := fmt.Sprintf("local d = vim.diagnostic.get(%d) if #d == 0 then return nil end return d", bufnr)
+	raw, err := callResult(ctx, c, func(n *nv.Nvim, out *[]map[string]any) error { return n.ExecLua(code, out) })
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// fetchBufferDiagnosticsChunked fetches the diagnostics of a buffer with
+// pathologically many of them in blocks of lines, so no single RPC response
+// carries them all. Blocks are sized assuming diagnostics are spread evenly.
+func fetchBufferDiagnosticsChunked(ctx context.Context, c *Client, info bufferInfo) ([]map[string]any, error) {
+	step := max(1, info.Lines*DiagnosticChunkSize/info.Diagnostics)
+	logger.Infof("nvim: fetching %d diagnostics of buffer %d in blocks of %d lines", info.Diagnostics, info.Bufnr, step)
+	items := make([]map[string]any, 0, info.Diagnostics)
+	for first := 0; first < info.Lines; first += step {
+		last := first + step
+		if last >= info.Lines {
+			// Diagnostics may lie past the end after edits
+			last = -1
+		}
+		jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+			return n.ExecLua(diagnosticsRangeLua, out, info.Bufnr, first, last)
+		})
+		if err != nil {
+			return nil, err
+		}
+		var chunk []map[string]any
+		if err := json.Unmarshal([]byte(jsonStr), &chunk); err != nil {
+			return nil, err
+		}
+		items = append(items, chunk...)
+	}
+	return items, nil
+}
+
+// refreshOutcome is the result of refresh_diagnostics.lua.
+type refreshOutcome struct {
+	Skipped  []string `msgpack:"skipped"`
+	Unloaded []string `msgpack:"unloaded"`
+	Editing  []string `msgpack:"editing"`
+	Mode     string   `msgpack:"mode"`
+}
+
+// refreshWorkspaceDiagnostics forces a refresh of workspace diagnostics for specific files.
+// Buffers with unsaved changes are not reloaded unless force is set; their
+// files are returned as skipped, and the other files as refreshed. Without
+// reload, buffers are not loaded or reloaded from disk at all and only the
+// already loaded ones are notified. When clients is non-empty, only the
+// clients with those names are notified. With guard, the buffer the user is
+// editing in insert, replace, visual or select mode is left alone with a
+// warning.
+func refreshWorkspaceDiagnostics(ctx context.Context, c *Client, files []string, workspace string, maxFiles int, force, reload bool, clients []string, guard bool) (refreshed, skipped []string, err error) {
 	var filesToProcess []string
 
 	if len(files) > 0 {
 		filesToProcess = files
 		if len(filesToProcess) > maxFiles {
 			filesToProcess = filesToProcess[:maxFiles]
-			logger.Warnf("nvim: capped user-specified files to %d", maxFiles)
+			c.warnf("capped the requested files to the first %d", maxFiles)
 		}
 	} else {
-		// Lua-based filtering for changed files
+		// Lua-based filtering for changed files. A headless session has no
+		// clients until files are opened, so there every file with a
+		// filetype is kept for the servers to start on
 		luaCode := filterLua
-		var jsonStr string
-		err := c.NV.ExecLua(luaCode, &jsonStr, workspace, maxFiles)
+		anyFiletype := c.method == ConnectHeadless
+		jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+			return n.ExecLua(luaCode, out, workspace, maxFiles, anyFiletype)
+		})
 		if err != nil {
-			logger.Errorf("nvim: Lua filtering failed: %v, skipping refresh", err)
-			return nil
+			c.warnf("listing changed files failed, skipping refresh: %v", err)
+			return nil, nil, nil
 		}
 		if jsonStr == "" || jsonStr == "null" {
-			logger.Errorf("nvim: Lua filtering returned empty result, skipping refresh")
-			return nil
+			c.warnf("listing changed files returned no result, skipping refresh")
+			return nil, nil, nil
 		}
 		var result luaFilterResult
 		if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-			logger.Errorf("nvim: Invalid JSON from Lua filtering: %v, skipping refresh", err)
-			return nil
+			c.warnf("invalid changed files result, skipping refresh: %v", err)
+			return nil, nil, nil
 		}
 		filesToProcess = result.Filtered
 		logger.Infof("nvim: Lua filtered %d changed files to %d relevant (max %d)", result.OrigCount, result.FilteredCount, maxFiles)
 		if len(filesToProcess) > maxFiles {
 			filesToProcess = filesToProcess[:maxFiles]
-			logger.Warnf("nvim: Capped post-Lua files to %d", maxFiles)
+			c.warnf("capped the changed files to the first %d", maxFiles)
 		}
 	}
 
 	if len(filesToProcess) == 0 {
-		return nil
+		return nil, nil, nil
 	}
 
 	// Refresh diagnostics for files by sending textDocument/didSave notifications
-	// Use ExecLua with args to properly pass the file list to Lua
+	// Use ExecLua with args to properly pass the file list to Lua, in batches
+	// so a big changeset does not produce one huge RPC message
 	code := refreshLua
 
-	return c.NV.ExecLua(code, nil, filesToProcess)
+	batches := batchBySize(filesToProcess, MaxLuaArgBytes)
+	if len(batches) > 1 {
+		logger.Infof("nvim: refreshing %d files in %d batches", len(filesToProcess), len(batches))
+	}
+	var unloaded, editing []string
+	done := 0
+	for _, batch := range batches {
+		c.progressf("reloading %d/%d files", done, len(filesToProcess))
+		outcome, err := callResult(ctx, c, func(n *nv.Nvim, out *refreshOutcome) error {
+			return n.ExecLua(code, out, batch, force, reload, clients, guard)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		skipped = append(skipped, outcome.Skipped...)
+		unloaded = append(unloaded, outcome.Unloaded...)
+		for _, file := range outcome.Editing {
+			c.warnf("skipped reloading %s: the user is editing it (mode %s)", file, outcome.Mode)
+		}
+		editing = append(editing, outcome.Editing...)
+		done += len(batch)
+	}
+	c.progressf("reloaded %d files", done)
+	if len(skipped) > 0 {
+		logger.Warnf("nvim: skipped reloading %d buffers with unsaved changes", len(skipped))
+	}
+	if len(unloaded) > 0 {
+		logger.Infof("nvim: not notifying %d files without a loaded buffer", len(unloaded))
+	}
+	for _, file := range filesToProcess {
+		if !slices.Contains(skipped, file) && !slices.Contains(unloaded, file) && !slices.Contains(editing, file) {
+			refreshed = append(refreshed, file)
+		}
+	}
+	return refreshed, skipped, nil
+}
+
+// batchBySize splits files into consecutive batches whose combined path length
+// stays within limit bytes. A single longer path gets a batch of its own.
+func batchBySize(files []string, limit int) [][]string {
+	var batches [][]string
+	var batch []string
+	size := 0
+	for _, f := range files {
+		if len(batch) > 0 && size+len(f) > limit {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, f)
+		size += len(f)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// workspaceFiles drops the files outside workspace, or fails listing them when
+// strict is set. A file counts as inside when either its path or, for a
+// symlink, its resolved target is within the (resolved) workspace.
+func workspaceFiles(c *Client, files []string, workspace string, strict bool) ([]string, error) {
+	if len(files) == 0 {
+		return files, nil
+	}
+	validatedFiles := make([]string, 0, len(files))
+	var outside []string
+	for _, file := range files {
+		// Check if file is absolute and within workspace
+		if !filepath.IsAbs(file) || !WithinWorkspace(file, workspace) {
+			c.warnf("file %s is outside workspace %s, skipping", file, workspace)
+			outside = append(outside, file)
+			continue
+		}
+		validatedFiles = append(validatedFiles, file)
+	}
+	if strict && len(outside) > 0 {
+		return nil, fmt.Errorf("files outside workspace %s: %s", workspace, strings.Join(outside, ", "))
+	}
+	return validatedFiles, nil
+}
+
+// Diagnostic is a single diagnostic collected from a Neovim buffer, with
+// 1-based line and column numbers.
+type Diagnostic struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Col  int    `json:"col"`
+	// ColMissing reports that the diagnostic had no column and Col is the
+	// default 1, only set when CollectOptions.OmitMissingCol is true.
+	ColMissing bool `json:"colMissing,omitempty"`
+	// EndLine and EndCol are the 1-based inclusive end of the diagnostic.
+	EndLine  int    `json:"endLine,omitempty"`
+	EndCol   int    `json:"endCol,omitempty"`
+	Severity string `json:"severity"`
+	// SeverityLevel is the numeric LSP severity (1 error to 4 hint) matching
+	// Severity.
+	SeverityLevel int    `json:"severityLevel"`
+	Message       string `json:"message"`
+	Source        string `json:"source,omitempty"`
+	Code          string `json:"code,omitempty"`
+	// Client is the LSP client (or non-LSP namespace) that produced the diagnostic.
+	Client string `json:"client,omitempty"`
+
+	// Filetype and Clients describe the originating buffer; they are only
+	// set when CollectOptions.IncludeContext is true.
+	Filetype string   `json:"filetype,omitempty"`
+	Clients  []string `json:"clients,omitempty"`
+	// Data is the server's opaque LSP diagnostic data (user_data.lsp.data),
+	// only set when CollectOptions.IncludeData is true.
+	Data json.RawMessage `json:"data,omitempty"`
+	// Range is the diagnostic's original LSP range (user_data.lsp.range),
+	// only set when CollectOptions.IncludeLspRange is true.
+	Range *LspRange `json:"range,omitempty"`
+	// Original is the LSP diagnostic as the server sent it
+	// (user_data.lsp), only kept for the lsp output format.
+	Original json.RawMessage `json:"-"`
+	// Fixable reports that a code action exists at the diagnostic, only
+	// probed when CollectOptions.CheckFixable is true, or that the server
+	// hinted at a quick fix in its data when CollectOptions.FixableHints is.
+	Fixable bool `json:"fixable,omitempty"`
+}
+
+// String renders the diagnostic as a single compiler-style line.
+func (d Diagnostic) String() string {
+	formatted := fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Col, severityLabel(d.Severity), d.Message)
+	if d.ColMissing {
+		formatted = fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, severityLabel(d.Severity), d.Message)
+	}
+	if d.Source != "" {
+		formatted += fmt.Sprintf(" (%s)", d.Source)
+	}
+	if d.Code != "" {
+		formatted += fmt.Sprintf(" [%s]", d.Code)
+	}
+	if d.Fixable {
+		formatted += " [fixable]"
+	}
+	return formatted
+}
+
+// TextColumns lists the fields CollectOptions.Columns can select.
+var TextColumns = []string{"file", "line", "col", "severity", "source", "code", "message"}
+
+// columns renders the named fields of d, tab-separated and in the given
+// order. Missing fields render empty, so every line has the same columns.
+func (d Diagnostic) columns(names []string) string {
+	fields := make([]string, len(names))
+	for i, name := range names {
+		switch name {
+		case "file":
+			fields[i] = d.File
+		case "line":
+			fields[i] = strconv.Itoa(d.Line)
+		case "col":
+			if !d.ColMissing {
+				fields[i] = strconv.Itoa(d.Col)
+			}
+		case "severity":
+			fields[i] = severityLabel(d.Severity)
+		case "source":
+			fields[i] = d.Source
+		case "code":
+			fields[i] = d.Code
+		case "message":
+			fields[i] = d.Message
+		}
+	}
+	return strings.Join(fields, "\t")
+}
+
+// CollectOptions controls which diagnostics are refreshed and collected.
+type CollectOptions struct {
+	// Files limits refresh and collection to these absolute paths. When empty,
+	// changed files (via git diff) are refreshed and all buffers collected.
+	Files []string
+	// StrictPaths fails the collection when a file is outside the workspace
+	// instead of skipping it.
+	StrictPaths bool
+	// Extensions limits collection to buffers whose names end in one of these
+	// extensions, with or without the leading dot.
+	Extensions []string
+	// ModifiedSince, when non-zero, limits collection to buffers whose file
+	// was modified (per its mtime) after this time.
+	ModifiedSince time.Time
+	// SkipBufferPrefixes extends PluginBufferPrefixes with more prefixes of
+	// buffer names that do not name files.
+	SkipBufferPrefixes []string
+	// Scope limits collection to ScopeAll (default), ScopeTab or ScopeWindow.
+	Scope string
+	// BufferSource further limits collection to BufferSourceAll (default),
+	// BufferSourceListed, BufferSourceLoaded or BufferSourceArgs buffers.
+	BufferSource string
+	// Format selects the output format, OutputText (default), OutputJSON,
+	// OutputGitHub, OutputMarkdown, OutputGroupedByCode,
+	// OutputGroupedBySeverity, OutputGroupedByEdit or OutputSummaryLine.
+	Format string
+	// Columns replaces the text output layout with these fields of
+	// TextColumns, tab-separated in the given order.
+	Columns []string
+	// Multiline renders messages with embedded newlines in text output:
+	// MultilineCollapse (the default) joins their lines with
+	// MessageSeparator, DefaultMessageSeparator when empty, and
+	// MultilineIndent indents the continuation lines under the first. JSON
+	// output always keeps the full message.
+	Multiline        string
+	MessageSeparator string
+	// Edits are the line ranges changed by the caller's edits, which
+	// OutputGroupedByEdit attributes the diagnostics to. Required by it.
+	Edits []Edit
+	// FetchShape selects how diagnostics are read from Neovim:
+	// FetchShapeDiagnostic (default) reads the vim.diagnostic.get items,
+	// FetchShapeQflist the entries of vim.diagnostic.toqflist, which lack
+	// the source, code and client. Buffers fetched in chunks always use the
+	// default shape.
+	FetchShape string
+	// OmitMissingCol marks diagnostics without a column as ColMissing, so
+	// they are rendered without one instead of with the default column 1.
+	OmitMissingCol bool
+	// IncludeContext adds the buffer filetype and attached LSP client names to
+	// each diagnostic. Only rendered in JSON output.
+	IncludeContext bool
+	// CheckFixable probes each returned diagnostic, up to MaxFixableProbes,
+	// for an available code action and marks it as fixable.
+	CheckFixable bool
+	// FixableHints marks diagnostics as fixable when their LSP data payload
+	// says a quick fix exists, without probing code actions. Only servers
+	// that populate such a hint are covered; see fixableHint.
+	FixableHints bool
+	// IncludeLspRange adds the original LSP range of each diagnostic. Only
+	// rendered in JSON output.
+	IncludeLspRange bool
+	// IncludeData preserves the LSP data payload servers attach to
+	// diagnostics for resolving code actions. Only rendered in JSON output.
+	IncludeData bool
+	// Force reloads buffers from disk even when they have unsaved changes,
+	// which may discard a user's in-progress edits.
+	Force bool
+	// NoReload skips loading and reloading buffers from disk, for setups
+	// that keep buffers in sync themselves (e.g. autoread); the already
+	// loaded buffers are still notified with didSave.
+	NoReload bool
+	// PreCollectLua is Lua code, or the absolute path of a .lua file, run in
+	// the session before the refresh, e.g. to enable diagnostics or start a
+	// server. It needs NVIM_LSP_MCP_PRE_COLLECT_LUA=1.
+	PreCollectLua string
+	// ReloadWhileEditing reloads the buffer the user is editing in insert,
+	// replace, visual or select mode too. By default it is left alone, with
+	// a warning, so a concurrent refresh does not disturb the user's typing.
+	ReloadWhileEditing bool
+	// RefreshClients limits the didSave notifications of the refresh to the
+	// LSP clients with these names, so only the relevant servers re-check
+	// the files. Empty means all clients.
+	RefreshClients []string
+	// EditedFileClients keeps only diagnostics produced by the LSP clients
+	// attached to Files, or without Files to the changed files refreshed,
+	// dropping those of clients and linters unrelated to the edited files
+	// (e.g. a spell checker on another open buffer). It combines with
+	// IncludeClients and ExcludeClients.
+	EditedFileClients bool
+	// IncludeClients keeps only diagnostics produced by these clients, and
+	// ExcludeClients drops those produced by them (see namespace_clients.lua).
+	IncludeClients []string
+	ExcludeClients []string
+	// Severities keeps only diagnostics with these severities (after
+	// SeverityOverrides are applied).
+	Severities []string
+	// SeverityOverrides remaps severities by "source:code" or, with lower
+	// precedence, by "source" key.
+	SeverityOverrides map[string]string
+	// MessageInclude keeps only diagnostics whose message matches this
+	// regular expression, and MessageExclude drops those matching it.
+	// Compiled by validate.
+	MessageInclude string
+	MessageExclude string
+	messageInclude *regexp.Regexp
+	messageExclude *regexp.Regexp
+	// PathBase makes the reported paths relative to this directory within
+	// the workspace, absolute or relative to the workspace.
+	PathBase string
+	// StripPrefix is removed from the start of the rendered file paths, for
+	// brevity. It only affects the output, not which files match.
+	StripPrefix string
+	// IncludeMeta records the Neovim cwd and git HEAD commit in the report.
+	IncludeMeta bool
+	// ChangedLinesOnly keeps only the diagnostics on lines changed relative
+	// to HEAD (staged or not, per git diff hunks), widened by ChangedContext
+	// lines on either side, dropping pre-existing issues elsewhere in changed
+	// files. Untracked files count as wholly changed.
+	ChangedLinesOnly bool
+	ChangedContext   int
+	// GitTrackedOnly keeps only diagnostics in files tracked by git. Ignored
+	// outside a git repository.
+	GitTrackedOnly bool
+	// FailOnErrors fails the lint gate if any error remains after filtering,
+	// and FailRules adds per-source thresholds. See Report.Gate.
+	FailOnErrors bool
+	FailRules    []FailRule
+	// ExcludeTests drops diagnostics in files matching TestPatterns, which
+	// are substrings of the workspace-relative path and default to
+	// DefaultTestPatterns.
+	ExcludeTests bool
+	TestPatterns []string
+	// Limit, when positive, caps the number of diagnostics and Budget the
+	// text size of the diagnostics in characters, keeping the most severe
+	// ones and summarizing the rest.
+	Limit  int
+	Budget int
+	// OverflowThreshold, when positive, keeps at most this many diagnostics
+	// (the most severe, like Limit) and writes the full set as JSON to
+	// OverflowPath, an absolute path or one relative to the workspace, by
+	// default DefaultOverflowFile. Report.OverflowPath references it.
+	OverflowThreshold int
+	OverflowPath      string
+	// Quickfix populates the session's quickfix list with the diagnostics.
+	// A list not created by this server is only overwritten with
+	// ReplaceQuickfix.
+	Quickfix        bool
+	ReplaceQuickfix bool
+	// MergeSessions also collects from every other discovered session whose
+	// cwd is the workspace, deduplicating diagnostics of buffers open in
+	// several. Only the attached session is refreshed.
+	MergeSessions bool
+	// FreshOnly keeps only diagnostics that were not already present right
+	// after the refresh, i.e. those the servers published while settling.
+	FreshOnly bool
+	// ReportFileClients records the LSP clients attached to each of Files in
+	// Report.FileClients.
+	ReportFileClients bool
+	// FullScan also collects the diagnostics of files that are not open:
+	// every git-tracked file of the workspace (or every file outside a git
+	// repository) with one of Extensions is loaded in batches, checked and
+	// unloaded again, up to MaxScanFiles. Slow on big workspaces.
+	FullScan bool
+	// ClientTimeout, when set, replaces the fixed SettleDelay with a wait
+	// that ends once no LSP client reports progress, giving up on each busy
+	// client after this long. Clients that never report progress are still
+	// given SettleDelay. The clients cut short either way are reported in
+	// Report.Partial.
+	ClientTimeout time.Duration
+	// RecheckAttempts re-polls an empty result up to this many times while
+	// LSP clients are attached and still report work in progress.
+	RecheckAttempts int
+}
+
+// CollectDiagnostics refreshes and collects diagnostics, rendered in the
+// requested output format.
+func CollectDiagnostics(ctx context.Context, c *Client, opts CollectOptions) (string, error) {
+	report, err := GatherDiagnostics(ctx, c, opts)
+	if err != nil {
+		return "", err
+	}
+	return Format(report, opts.Format)
 }
 
-// CollectDiagnosticsJSON collects diagnostics for all listed buffers as JSON, using the injected Lua function.
-func CollectDiagnostics(ctx context.Context, c *Client, files []string) (string, error) {
+// GatherDiagnostics refreshes the requested files (or the changed files when
+// none are given), waits for LSP servers to settle and returns the diagnostics
+// of all matching buffers in scope.
+func GatherDiagnostics(ctx context.Context, c *Client, opts CollectOptions) (*Report, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	files, err := filePaths(opts.Files)
+	if err != nil {
+		return nil, err
+	}
+
 	// Minimal context
 	if cwd, err := GetCwd(ctx, c); err == nil {
 		logger.Infof("nvim: cwd=%s", cwd)
@@ -107,21 +644,28 @@ func CollectDiagnostics(ctx context.Context, c *Client, files []string) (string,
 	// Get workspace directory
 	workspace, err := GetCwd(ctx, c)
 	if err != nil {
-		return "", fmt.Errorf("failed to get workspace: %w", err)
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	c.workspace = workspace
+	c.warnings = nil
+	if c.requested != "" && c.requested != workspace {
+		c.warnf("Neovim session cwd %s differs from the requested workspace %s (lenient discovery); diagnostics are collected for the session cwd", workspace, c.requested)
 	}
 
 	// Validate file paths are within workspace
-	if len(files) > 0 {
-		validatedFiles := make([]string, 0, len(files))
-		for _, file := range files {
-			// Check if file is absolute and within workspace
-			if !strings.HasPrefix(file, workspace) {
-				logger.Warnf("nvim: file %s is outside workspace %s, skipping", file, workspace)
-				continue
-			}
-			validatedFiles = append(validatedFiles, file)
+	files, err = workspaceFiles(c, files, workspace, opts.StrictPaths)
+	if err != nil {
+		return nil, err
+	}
+	pathBase, err := resolvePathBase(opts.PathBase, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.PreCollectLua != "" {
+		if err := runPreCollectLua(ctx, c, opts.PreCollectLua); err != nil {
+			return nil, err
 		}
-		files = validatedFiles
 	}
 
 	// Refresh workspace diagnostics before collecting
@@ -130,117 +674,613 @@ func CollectDiagnostics(ctx context.Context, c *Client, files []string) (string,
 	} else {
 		logger.Infof("nvim: refreshing workspace diagnostics for %d files", len(files))
 	}
-	if err := refreshWorkspaceDiagnostics(c, files, workspace, MaxFilesToReload); err != nil {
-		logger.Warnf("nvim: failed to refresh workspace diagnostics: %v", err)
+	refreshed, skipped, err := refreshWorkspaceDiagnostics(ctx, c, files, workspace, MaxFilesToReload, opts.Force, !opts.NoReload, opts.RefreshClients, !opts.ReloadWhileEditing)
+	if err != nil {
+		c.warnf("failed to refresh workspace diagnostics: %v", err)
 		// Continue anyway - diagnostics might still be available
 	}
 
+	if c.method == ConnectHeadless {
+		c.progressf("waiting for LSP clients to attach to the headless session")
+		if err := waitForHeadlessClients(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	// Snapshot the markers present before the servers had time to respond
+	var stale map[diagnosticKey]int
+	if opts.FreshOnly {
+		bufs, err := sourceBuffers(ctx, c, opts.Scope, opts.BufferSource)
+		if err != nil {
+			return nil, err
+		}
+		// Fetch the snapshot with the same options as the final collection
+		// so both sides have the same shape
+		snapshot := opts
+		snapshot.Files = files
+		before, err := collectBufferDiagnostics(ctx, c, bufs, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		stale = make(map[diagnosticKey]int, len(before))
+		for _, d := range before {
+			stale[d.key()]++
+		}
+		logger.Infof("nvim: %d diagnostics present before settling", len(before))
+	}
+
 	// Give LSP servers a moment to process the refresh notifications
 	logger.Infof("nvim: waiting for LSP to reload diagnostics...")
-	time.Sleep(3 * time.Second)
+	var partial []string
+	if opts.ClientTimeout > 0 {
+		c.progressf("waiting up to %s per LSP client to publish diagnostics", opts.ClientTimeout)
+		if partial, err = waitForClients(ctx, c, opts.ClientTimeout); err != nil {
+			return nil, err
+		}
+		for _, name := range partial {
+			c.warnf("partial (%s timed out after %s): its diagnostics may be incomplete", name, opts.ClientTimeout)
+		}
+	} else {
+		c.progressf("waiting %s for LSP to publish diagnostics", SettleDelay)
+		if err := sleep(ctx, SettleDelay); err != nil {
+			return nil, err
+		}
+	}
 
-	// Use RPC for buffer list and buffer metadata
-	var bufs []int
-	if err := c.NV.Call("nvim_list_bufs", &bufs); err != nil {
-		return "", err
+	opts.Files = files
+	var others []*Client
+	if opts.MergeSessions {
+		others = DiscoverAllByCwd(ctx, workspace, c.addr)
+		defer func() {
+			for _, other := range others {
+				other.Close()
+			}
+		}()
+		logger.Infof("nvim: merging diagnostics from %d other sessions", len(others))
+	}
+	var tracked map[string]bool
+	if opts.GitTrackedOnly {
+		if tracked = gitTrackedFiles(ctx, workspace); tracked == nil {
+			c.warnf("%s is not a git repository, ignoring gitTrackedOnly", workspace)
+		}
+	}
+	var edited []string
+	if opts.EditedFileClients {
+		targets := files
+		if len(targets) == 0 {
+			targets = refreshed
+		}
+		if edited, err = attachedClients(ctx, c, targets); err != nil {
+			c.warnf("listing the clients of the edited files failed, ignoring editedFileClients: %v", err)
+		} else if len(edited) == 0 {
+			c.warnf("no LSP client is attached to the %d edited files, ignoring editedFileClients", len(targets))
+		} else {
+			logger.Infof("nvim: keeping diagnostics of the edited files' clients %s", strings.Join(edited, ", "))
+		}
+	}
+	var hunks changedLines
+	if opts.ChangedLinesOnly {
+		if hunks, err = gitChangedLines(ctx, workspace, opts.ChangedContext); err != nil {
+			c.warnf("listing the changed lines failed, ignoring changedLinesOnly: %v", err)
+		} else {
+			logger.Infof("nvim: keeping diagnostics on changed lines of %s", hunks)
+		}
+	}
+	var scanned []Diagnostic
+	if opts.FullScan {
+		if scanned, err = scanWorkspace(ctx, c, workspace, opts); err != nil {
+			return nil, err
+		}
+	}
+	ignore := loadIgnoreRules(workspace)
+	var buffersChecked int
+	collect := func() ([]Diagnostic, error) {
+		bufs, err := sourceBuffers(ctx, c, opts.Scope, opts.BufferSource)
+		if err != nil {
+			return nil, err
+		}
+		buffersChecked = len(bufs)
+		c.progressf("collecting diagnostics from %d buffers", len(bufs))
+		diags, err := collectBufferDiagnostics(ctx, c, bufs, opts)
+		if err != nil {
+			return nil, err
+		}
+		diags = mergeDiagnostics(diags, scanned)
+		// A failing session is closed and left out of later rechecks
+		others = slices.DeleteFunc(others, func(other *Client) bool {
+			var err error
+			if diags, err = mergeSession(ctx, other, diags, opts); err != nil {
+				c.warnf("failed to collect from session %s: %v", other.addr, err)
+				other.Close()
+				return true
+			}
+			return false
+		})
+		if stale != nil {
+			diags = dropStale(diags, stale)
+		}
+		diags = reduceDiagnostics(diags, workspace, opts)
+		if len(ignore) > 0 {
+			diags = slices.DeleteFunc(diags, func(d Diagnostic) bool { return ignore.ignored(d.File, workspace) })
+		}
+		if tracked != nil {
+			diags = slices.DeleteFunc(diags, func(d Diagnostic) bool { return !tracked[d.File] })
+		}
+		if len(edited) > 0 {
+			diags = slices.DeleteFunc(diags, func(d Diagnostic) bool { return !clientAllowed(d.Client, edited, nil) })
+		}
+		if hunks != nil {
+			diags = slices.DeleteFunc(diags, func(d Diagnostic) bool { return !hunks.touches(d) })
+		}
+		return diags, nil
+	}
+
+	diags, err := collect()
+	if err != nil {
+		return nil, err
+	}
+	// A slow server may not have published yet; an empty result is only
+	// trusted once no client reports work in progress
+	for attempt := 1; len(diags) == 0 && attempt <= opts.RecheckAttempts; attempt++ {
+		if !lspBusy(ctx, c) {
+			break
+		}
+		logger.Infof("nvim: empty result while LSP is busy, recheck %d/%d", attempt, opts.RecheckAttempts)
+		c.progressf("LSP still busy, recheck %d/%d", attempt, opts.RecheckAttempts)
+		if err := sleep(ctx, RecheckDelay); err != nil {
+			return nil, err
+		}
+		if diags, err = collect(); err != nil {
+			return nil, err
+		}
+	}
+	logger.Infof("nvim: diagnostics_total=%d", len(diags))
+
+	report := &Report{
+		Diagnostics:      diags,
+		SkippedUnsaved:   skipped,
+		BuffersChecked:   buffersChecked,
+		FilesRefreshed:   len(refreshed),
+		Workspace:        workspace,
+		StripPrefix:      opts.StripPrefix,
+		Edits:            opts.Edits,
+		Columns:          opts.Columns,
+		Multiline:        opts.Multiline,
+		MessageSeparator: opts.MessageSeparator,
+		Partial:          partial,
+	}
+	if opts.IncludeMeta {
+		report.Meta = &Meta{Cwd: workspace, Connection: c.Connection()}
+		if commit, err := runGit(ctx, workspace, "rev-parse", "HEAD"); err == nil {
+			report.Meta.Commit = commit
+		} else {
+			logger.Infof("nvim: no git commit for %s: %v", workspace, err)
+		}
+	}
+	// Gate on everything collected, before the budget trims anything
+	rules := opts.FailRules
+	if opts.FailOnErrors {
+		rules = append([]FailRule{failOnErrorsRule}, rules...)
+	}
+	if len(rules) > 0 {
+		report.Gate = evaluateGate(diags, rules)
+		if report.Gate.Failed {
+			logger.Infof("nvim: lint gate failed: %v", report.Gate.Tripped)
+		}
+	}
+	limit := opts.Limit
+	if opts.OverflowThreshold > 0 && len(diags) > opts.OverflowThreshold {
+		if path, err := writeOverflow(report, slices.Clone(diags), opts, workspace); err != nil {
+			c.warnf("failed to write the overflowing diagnostics: %v", err)
+		} else {
+			report.OverflowPath = path
+			logger.Infof("nvim: %d diagnostics exceed the overflow threshold %d, wrote all to %s", len(diags), opts.OverflowThreshold, path)
+		}
+		if limit <= 0 || limit > opts.OverflowThreshold {
+			limit = opts.OverflowThreshold
+		}
+	}
+	if limit > 0 || opts.Budget > 0 {
+		report.Diagnostics, report.Omitted = trimDiagnostics(diags, limit, opts.Budget)
+		if len(report.Omitted) > 0 {
+			logger.Infof("nvim: trimmed to limit %d and budget %d, kept %d of %d diagnostics", limit, opts.Budget, len(report.Diagnostics), len(diags))
+		}
+	}
+	if opts.CheckFixable {
+		if err := markFixable(ctx, c, report.Diagnostics); err != nil {
+			c.warnf("failed to probe code actions: %v", err)
+		}
 	}
+	if opts.Quickfix {
+		count, err := setQuickfix(ctx, c, report.Diagnostics, opts.ReplaceQuickfix)
+		if err != nil {
+			c.warnf("failed to set quickfix list: %v", err)
+			report.Quickfix = fmt.Sprintf("quickfix list not updated: %v", err)
+		} else {
+			report.Quickfix = fmt.Sprintf("set %d quickfix entries", count)
+		}
+	}
+	if pathBase != "" {
+		report.relativize(pathBase, workspace)
+	}
+	if opts.ReportFileClients && len(files) > 0 {
+		if report.FileClients, err = fileClients(ctx, c, files); err != nil {
+			c.warnf("failed to list LSP clients of the requested files: %v", err)
+		}
+	}
+	report.Warnings = c.warnings
+	return report, nil
+}
+
+// collectBufferDiagnostics reads diagnostics from the named buffers in bufs,
+// or only from the buffers of opts.Files when it is non-empty. It does not
+// refresh anything.
+func collectBufferDiagnostics(ctx context.Context, c *Client, bufs []int, opts CollectOptions) ([]Diagnostic, error) {
 	logger.Infof("nvim: buffers_total=%d", len(bufs))
 	if len(bufs) == 0 {
 		logger.Warnf("nvim: no buffers returned by nvim_list_bufs")
+		return nil, nil
+	}
+
+	// Fetch metadata for all buffers in one call rather than several per buffer
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(bufferInfoLua, out, bufs) })
+	if err != nil {
+		return nil, err
+	}
+	var infos []bufferInfo
+	if err := json.Unmarshal([]byte(jsonStr), &infos); err != nil {
+		return nil, fmt.Errorf("invalid buffer info: %w", err)
+	}
+
+	// Match requested files by their resolved path, since a buffer may be
+	// named after a symlink's target or the other way round
+	wanted := make(map[string]bool, len(opts.Files))
+	for _, f := range opts.Files {
+		wanted[canonicalPath(f)] = true
+	}
+
+	// Resolve which client produced each diagnostic namespace
+	var namespaces map[string]string
+	if nsJSON, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(namespaceClientsLua, out) }); err != nil {
+		c.warnf("failed to resolve diagnostic namespaces: %v", err)
+	} else if err := json.Unmarshal([]byte(nsJSON), &namespaces); err != nil {
+		c.warnf("invalid namespace map: %v", err)
 	}
 
-	var lines []string
+	var diags []Diagnostic
+	// Buffers per client name attached more than once, which duplicates
+	// diagnostics and usually means the server is set up twice
+	duplicates := make(map[string][]string)
 
-	for _, bnr := range bufs {
-		var valid bool
-		if err := c.NV.Call("nvim_buf_is_valid", &valid, bnr); err != nil {
-			logger.Errorf("nvim: nvim_buf_is_valid(%d) error: %v", bnr, err)
+	for _, info := range infos {
+		if info.Name == "" {
+			// Skip unnamed buffers
 			continue
 		}
-		if !valid {
+		if isPluginBuffer(info.Name, opts.SkipBufferPrefixes) {
+			logger.Infof("nvim: skipping non-file buffer %d (%s)", info.Bufnr, info.Name)
 			continue
 		}
-		var name string
-		if err := c.NV.Call("nvim_buf_get_name", &name, bnr); err != nil {
-			logger.Errorf("nvim: nvim_buf_get_name(%d) error: %v", bnr, err)
+		// A file:// buffer name still names a file
+		info.Name = bufferPath(info.Name)
+
+		if len(opts.Extensions) > 0 && !hasExtension(info.Name, opts.Extensions) {
 			continue
 		}
-		if name == "" {
-			// Skip unnamed buffers
+		if !opts.ModifiedSince.IsZero() && !modifiedAfter(info.Name, opts.ModifiedSince) {
 			continue
 		}
 
 		// If specific files were requested, only include diagnostics for those files
-		if len(files) > 0 {
-			if !slices.Contains(files, name) {
+		if len(opts.Files) > 0 {
+			if !wanted[canonicalPath(info.Name)] {
 				continue
 			}
 		}
 
+		for _, name := range duplicateClients(info.Clients) {
+			duplicates[name] = append(duplicates[name], info.Name)
+		}
+
 		// Fetch diagnostics directly from vim.diagnostic.get
-		items, err := fetchBufferDiagnostics(c, bnr)
-		if err != nil {
-			logger.Errorf("nvim: diagnostic.get(%d) error: %v", bnr, err)
-			continue
+		var items []map[string]any
+		var err error
+		if info.Diagnostics > DiagnosticChunkSize && info.Lines > 1 {
+			items, err = fetchBufferDiagnosticsChunked(ctx, c, info)
+		} else {
+			items, err = fetchBufferDiagnostics(ctx, c, info.Bufnr, opts.FetchShape)
 		}
-		if len(items) == 0 {
+		if err != nil {
+			logger.Errorf("nvim: diagnostic.get(%d) error: %v", info.Bufnr, err)
 			continue
 		}
 		for _, item := range items {
-			severityRaw, ok := item["severity"].(float64)
+			d, ok := parseDiagnostic(info.Name, item)
 			if !ok {
 				continue
 			}
-			severityInt := int(severityRaw)
-			var severityStr string
-			switch severityInt {
-			case 1:
-				severityStr = "error"
-			case 2:
-				severityStr = "warning"
-			case 3:
-				severityStr = "info"
-			case 4:
-				severityStr = "hint"
-			default:
-				severityStr = "unknown"
+			if opts.OmitMissingCol {
+				_, hasCol := item["col"].(float64)
+				d.ColMissing = !hasCol
 			}
-
-			lnumRaw, ok := item["lnum"].(float64)
-			if !ok {
-				continue
+			if ns, ok := item["namespace"].(float64); ok {
+				d.Client = namespaces[strconv.Itoa(int(ns))]
 			}
-			line := int(lnumRaw) + 1
-
-			colRaw, ok := item["col"].(float64)
-			col := 1
-			if ok {
-				col = int(colRaw) + 1
+			if opts.IncludeContext {
+				d.Filetype = info.Filetype
+				d.Clients = info.Clients
 			}
-
-			msg, ok := item["message"].(string)
-			if !ok || msg == "" {
-				continue
+			if opts.IncludeData {
+				d.Data = lspData(item)
 			}
-
-			source, _ := item["source"].(string)
-			codeRaw := item["code"]
-			var codeStr string
-			if codeRaw != nil {
-				codeStr = fmt.Sprintf("%v", codeRaw)
+			if opts.FixableHints {
+				d.Fixable = fixableHint(item)
 			}
-
-			formatted := fmt.Sprintf("%s:%d:%d: %s: %s", name, line, col, strings.ToUpper(severityStr), msg)
-			if source != "" {
-				formatted += fmt.Sprintf(" (%s)", source)
+			if opts.IncludeLspRange {
+				d.Range = lspRange(item)
 			}
-			if codeStr != "" {
-				formatted += fmt.Sprintf(" [%s]", codeStr)
+			if opts.Format == OutputLSP {
+				d.Original = lspOriginal(item)
 			}
-			lines = append(lines, formatted)
+			diags = append(diags, d)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(duplicates)) {
+		files := duplicates[name]
+		c.warnf("LSP client %s is attached more than once to %d buffers (e.g. %s); it is probably configured twice in your Neovim config", name, len(files), files[0])
+	}
+	return diags, nil
+}
+
+// duplicateClients returns the client names that occur more than once in
+// clients.
+func duplicateClients(clients []string) []string {
+	var dups []string
+	seen := make(map[string]bool, len(clients))
+	for _, name := range clients {
+		if seen[name] && !slices.Contains(dups, name) {
+			dups = append(dups, name)
+		}
+		seen[name] = true
+	}
+	return dups
+}
+
+// mergeDiagnostics adds the diagnostics of more to diags, skipping those
+// already present.
+func mergeDiagnostics(diags, more []Diagnostic) []Diagnostic {
+	if len(more) == 0 {
+		return diags
+	}
+	seen := make(map[diagnosticKey]bool, len(diags))
+	for _, d := range diags {
+		seen[d.key()] = true
+	}
+	for _, d := range more {
+		if !seen[d.key()] {
+			seen[d.key()] = true
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
+
+// mergeSession adds the diagnostics of another session to diags, skipping
+// those already present, e.g. for a buffer open in both sessions. If the
+// session fails, diags is returned unchanged with the error.
+func mergeSession(ctx context.Context, c *Client, diags []Diagnostic, opts CollectOptions) ([]Diagnostic, error) {
+	bufs, err := listBuffers(ctx, c, ScopeAll)
+	if err == nil {
+		var more []Diagnostic
+		if more, err = collectBufferDiagnostics(ctx, c, bufs, opts); err == nil {
+			return mergeDiagnostics(diags, more), nil
+		}
+	}
+	return diags, err
+}
+
+// diagnosticKey identifies a diagnostic independently of how it is rendered.
+type diagnosticKey struct {
+	file                            string
+	line, col, endLine, endCol, sev int
+	source, code, message           string
+}
+
+// key returns the identity of d: its file, range, severity, source, code and
+// message.
+func (d Diagnostic) key() diagnosticKey {
+	return diagnosticKey{
+		file:    d.File,
+		line:    d.Line,
+		col:     d.Col,
+		endLine: d.EndLine,
+		endCol:  d.EndCol,
+		sev:     d.SeverityLevel,
+		source:  d.Source,
+		code:    d.Code,
+		message: d.Message,
+	}
+}
+
+// dropStale removes the diagnostics counted in stale, matching repeated
+// identical diagnostics as a multiset. stale is left untouched.
+func dropStale(diags []Diagnostic, stale map[diagnosticKey]int) []Diagnostic {
+	remaining := maps.Clone(stale)
+	return slices.DeleteFunc(diags, func(d Diagnostic) bool {
+		key := d.key()
+		if remaining[key] > 0 {
+			remaining[key]--
+			return true
+		}
+		return false
+	})
+}
+
+// LspRange is a range as sent by the LSP server, with 0-based lines and
+// characters in the server's position encoding.
+type LspRange struct {
+	Start LspPosition `json:"start"`
+	End   LspPosition `json:"end"`
+}
+
+// LspPosition is a position within an LspRange.
+type LspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange returns the user_data.lsp.range of a diagnostic item, or nil if it
+// has none (e.g. diagnostics not produced by an LSP client).
+func lspRange(item map[string]any) *LspRange {
+	userData, _ := item["user_data"].(map[string]any)
+	lsp, _ := userData["lsp"].(map[string]any)
+	raw, ok := lsp["range"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var r LspRange
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil
+	}
+	return &r
+}
+
+// lspData returns the user_data.lsp.data payload of a diagnostic item, or nil
+// if it has none.
+func lspData(item map[string]any) json.RawMessage {
+	userData, _ := item["user_data"].(map[string]any)
+	lsp, _ := userData["lsp"].(map[string]any)
+	data, ok := lsp["data"]
+	if !ok || data == nil {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// parseDiagnostic converts a decoded vim.diagnostic item into a Diagnostic.
+// It reports false for items missing a severity, line or message.
+func parseDiagnostic(name string, item map[string]any) (Diagnostic, bool) {
+	severityRaw, ok := item["severity"].(float64)
+	if !ok {
+		return Diagnostic{}, false
+	}
+	severityInt := int(severityRaw)
+	var severityStr string
+	switch severityInt {
+	case 1:
+		severityStr = "error"
+	case 2:
+		severityStr = "warning"
+	case 3:
+		severityStr = "info"
+	case 4:
+		severityStr = "hint"
+	default:
+		severityStr = "unknown"
+	}
+
+	lnumRaw, ok := item["lnum"].(float64)
+	if !ok {
+		return Diagnostic{}, false
+	}
+	line := int(lnumRaw) + 1
+
+	colRaw, ok := item["col"].(float64)
+	col := 1
+	if ok {
+		col = int(colRaw) + 1
+	}
+
+	msg, ok := item["message"].(string)
+	if !ok || msg == "" {
+		return Diagnostic{}, false
+	}
+
+	// end_col is exclusive, so as a 1-based inclusive column it is unchanged
+	endLine, endCol := line, col
+	if v, ok := item["end_lnum"].(float64); ok {
+		endLine = int(v) + 1
+	}
+	if v, ok := item["end_col"].(float64); ok {
+		endCol = int(v)
+	}
+	if endLine < line || (endLine == line && endCol < col) {
+		endLine, endCol = line, col
+	}
+
+	source, _ := item["source"].(string)
+	codeStr := formatCode(item["code"])
+
+	return Diagnostic{
+		File:          name,
+		Line:          line,
+		Col:           col,
+		EndLine:       endLine,
+		EndCol:        endCol,
+		Severity:      severityStr,
+		SeverityLevel: severityInt,
+		Message:       msg,
+		Source:        source,
+		Code:          codeStr,
+	}, true
+}
+
+// formatCode renders a diagnostic code. JSON decoding turns integer codes
+// (e.g. TypeScript's 2304) into float64, so whole numbers are rendered without
+// a fractional part or exponent.
+func formatCode(code any) string {
+	switch v := code.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return strconv.FormatInt(int64(v), 10)
 		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// lspStatus is the result of lsp_busy.lua.
+type lspStatus struct {
+	Clients     int      `json:"clients"`
+	ClientNames []string `json:"clientNames"`
+	Busy        bool     `json:"busy"`
+	BusyClients []string `json:"busyClients"`
+}
+
+// lspProgress returns which LSP clients still have $/progress work pending.
+func lspProgress(ctx context.Context, c *Client) (lspStatus, error) {
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(lspBusyLua, out) })
+	if err != nil {
+		return lspStatus{}, err
 	}
+	var status lspStatus
+	if err := json.Unmarshal([]byte(jsonStr), &status); err != nil {
+		return lspStatus{}, fmt.Errorf("invalid LSP progress: %w", err)
+	}
+	return status, nil
+}
 
-	logger.Infof("nvim: diagnostics_total=%d", len(lines))
-	return strings.Join(lines, "\n"), nil
+// lspBusy reports whether LSP clients are attached and any of them still has
+// $/progress work pending.
+func lspBusy(ctx context.Context, c *Client) bool {
+	status, err := lspProgress(ctx, c)
+	if err != nil {
+		c.warnf("failed to query LSP progress: %v", err)
+		return false
+	}
+	return status.Clients > 0 && status.Busy
 }
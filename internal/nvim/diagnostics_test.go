@@ -0,0 +1,155 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFormatCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code any
+		want string
+	}{
+		{"nil", nil, ""},
+		{"string", "E0308", "E0308"},
+		{"numeric string", "2322", "2322"},
+		{"int", 2322, "2322"},
+		{"int64", int64(-7), "-7"},
+		{"uint64", uint64(42), "42"},
+		{"whole float", float64(2322), "2322"},
+		{"negative whole float", float64(-1), "-1"},
+		{"zero float", float64(0), "0"},
+		{"fractional float", 1.5, "1.5"},
+		{"large float", 1e20, "100000000000000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCode(tt.code); got != tt.want {
+				t.Errorf("formatCode(%#v) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeSessions(t *testing.T) {
+	ctx := context.Background()
+	shared := fakeDiagnostic(4, 2, 1, 10, "undefined: foo")
+	first := newFakeClient(t, "first", fakeSession{
+		buffers: []fakeBuffer{
+			{name: "/ws/a.go", clients: []string{"gopls"}, diags: []map[string]any{shared}},
+		},
+		namespaces: map[string]string{"10": "gopls"},
+	})
+	// The second session numbers namespaces differently and has another
+	// buffer open, with a diagnostic from a non-LSP linter
+	second := newFakeClient(t, "second", fakeSession{
+		buffers: []fakeBuffer{
+			{name: "/ws/a.go", clients: []string{"gopls"}, diags: []map[string]any{fakeDiagnostic(4, 2, 1, 20, "undefined: foo")}},
+			{name: "/ws/README.md", diags: []map[string]any{fakeDiagnostic(0, 0, 4, 21, "misspelled word")}},
+			{name: "oil:///ws/", diags: []map[string]any{fakeDiagnostic(0, 0, 1, 21, "plugin buffer")}},
+		},
+		namespaces: map[string]string{"20": "gopls", "21": "typos"},
+	})
+
+	bufs, err := listBuffers(ctx, first, ScopeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags, err := collectBufferDiagnostics(ctx, first, bufs, CollectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags, err = mergeSession(ctx, second, diags, CollectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		file, client, message string
+		line, col             int
+	}{
+		{"/ws/a.go", "gopls", "undefined: foo", 5, 3},
+		{"/ws/README.md", "typos", "misspelled word", 1, 1},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("merged %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.File != w.file || d.Client != w.client || d.Message != w.message || d.Line != w.line || d.Col != w.col {
+			t.Errorf("diagnostic %d = %s (client %q), want %s:%d:%d %s (client %q)", i, d, d.Client, w.file, w.line, w.col, w.message, w.client)
+		}
+	}
+
+	// A session that fails leaves the diagnostics unchanged
+	broken := newFakeClient(t, "broken", fakeSession{})
+	broken.Close()
+	merged, err := mergeSession(ctx, broken, diags, CollectOptions{})
+	if err == nil {
+		t.Error("mergeSession with a closed session succeeded")
+	}
+	if len(merged) != len(diags) {
+		t.Errorf("failed merge changed the diagnostics to %v", merged)
+	}
+}
+
+func TestDropStale(t *testing.T) {
+	old := Diagnostic{File: "/ws/a.go", Line: 3, Col: 1, SeverityLevel: 1, Severity: "error", Message: "undefined: x", Source: "compiler"}
+	stale := map[diagnosticKey]int{old.key(): 1}
+
+	// Rendering details such as a missing column or fixable hints must not
+	// keep a stale diagnostic from matching
+	rendered := old
+	rendered.ColMissing = true
+	rendered.Fixable = true
+	fresh := old
+	fresh.Message = "undefined: y"
+
+	got := dropStale([]Diagnostic{rendered, fresh, old}, stale)
+	if len(got) != 2 || got[0].Message != "undefined: y" || got[1].ColMissing {
+		t.Errorf("dropStale() = %v, want the fresh diagnostic and the repeated one", got)
+	}
+	if stale[old.key()] != 1 {
+		t.Errorf("dropStale() modified stale: %v", stale)
+	}
+}
+
+func TestFetchBufferDiagnosticsWithoutVimJSON(t *testing.T) {
+	for _, noVimJSON := range []bool{false, true} {
+		// The vim.json support is cached per session address
+		addr := fmt.Sprintf("fake-vimjson-%v", !noVimJSON)
+		c := newFakeClient(t, addr, fakeSession{
+			buffers:   []fakeBuffer{{name: "/ws/a.go", diags: []map[string]any{fakeDiagnostic(4, 2, 1, 7, "undefined: x")}}, {name: "/ws/b.go"}},
+			noVimJSON: noVimJSON,
+		})
+		items, err := fetchBufferDiagnostics(context.Background(), c, 1, FetchShapeDiagnostic)
+		if err != nil {
+			t.Fatalf("noVimJSON=%v: %v", noVimJSON, err)
+		}
+		if len(items) != 1 || items[0]["lnum"] != float64(4) || items[0]["message"] != "undefined: x" {
+			t.Errorf("noVimJSON=%v: items = %v, want the diagnostic with JSON number types", noVimJSON, items)
+		}
+		if items, err := fetchBufferDiagnostics(context.Background(), c, 2, FetchShapeDiagnostic); err != nil || len(items) != 0 {
+			t.Errorf("noVimJSON=%v: empty buffer gave %v, %v", noVimJSON, items, err)
+		}
+	}
+}
+
+func TestMergeDiagnostics(t *testing.T) {
+	d := Diagnostic{File: "/ws/a.go", Line: 5, Col: 3, EndLine: 5, EndCol: 6, SeverityLevel: 1, Severity: "error", Message: "undefined: foo", Source: "compiler"}
+
+	// The same diagnostic rendered differently by the other session
+	fixable := d
+	fixable.Fixable = true
+	noCol := d
+	noCol.ColMissing = true
+	// A different diagnostic that renders the same
+	wider := d
+	wider.EndCol = 9
+
+	got := mergeDiagnostics([]Diagnostic{d}, []Diagnostic{fixable, noCol, wider, wider})
+	if len(got) != 2 || got[1].EndCol != 9 {
+		t.Errorf("mergeDiagnostics() = %v, want the original and the wider range once", got)
+	}
+}
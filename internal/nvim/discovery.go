@@ -7,10 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"time"
 
-	nv "github.com/neovim/go-client/nvim"
-
 	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
 )
 
@@ -80,33 +79,157 @@ func discoverSocketCandidates() []string {
 // DiscoverAndConnectByCwd tries all discovered sockets and returns the client whose cwd matches workspace.
 func DiscoverAndConnectByCwd(ctx context.Context, workspace string) (*Client, error) {
 	for _, addr := range discoverSocketCandidates() {
-		logger.Infof("nvim discovery: trying %s", addr)
-		conn, err := net.DialTimeout("unix", addr, 1*time.Second)
+		cli, cwd, err := connectCandidate(ctx, addr)
 		if err != nil {
-			logger.Warnf("nvim discovery: dial timeout or failed for %s: %v", addr, err)
 			continue
 		}
-		conn.Close()
+		if cwd == workspace {
+			logger.Infof("nvim discovery: matched workspace cwd=%s at %s", cwd, addr)
+			cli.workspace = workspace
+			return cli, nil
+		}
+		cli.Close()
+	}
+	return nil, errors.New("no Neovim sessions found matching workspace cwd")
+}
+
+// LenientDiscovery lets discovery fall back to a session whose cwd contains
+// the workspace, or lies within it, when no session's cwd equals it. Such a
+// match is reported as a warning of every collection.
+var LenientDiscovery = false
 
-		n, err := nv.Dial(addr)
+// DiscoverAndConnectContaining returns the client of a session whose cwd
+// contains workspace, the nearest one first, or else of a session whose cwd
+// lies within workspace. It is the lenient fallback of DiscoverAndConnectByCwd.
+func DiscoverAndConnectContaining(ctx context.Context, workspace string) (*Client, error) {
+	var best *Client
+	var bestCwd string
+	// rank orders matches: a nearer containing cwd beats a farther one, and
+	// any containing cwd beats a nested one
+	rank := func(cwd string) int {
+		if withinDir(workspace, cwd) {
+			return len(cwd)
+		}
+		return -1
+	}
+	for _, addr := range discoverSocketCandidates() {
+		cli, cwd, err := connectCandidate(ctx, addr)
 		if err != nil {
-			logger.Warnf("nvim discovery: full dial failed for %s: %v", addr, err)
 			continue
 		}
-		cli := &Client{NV: n}
-		getcwdCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
-		defer cancel()
-		cwd, err := GetCwd(getcwdCtx, cli)
+		if (!withinDir(workspace, cwd) && !withinDir(cwd, workspace)) || (best != nil && rank(cwd) <= rank(bestCwd)) {
+			cli.Close()
+			continue
+		}
+		if best != nil {
+			best.Close()
+		}
+		best, bestCwd = cli, cwd
+	}
+	if best == nil {
+		return nil, errors.New("no Neovim sessions found containing or within workspace")
+	}
+	logger.Infof("nvim discovery: leniently matched workspace %s to session cwd=%s at %s", workspace, bestCwd, best.addr)
+	best.requested, best.workspace = workspace, bestCwd
+	return best, nil
+}
+
+// DiscoverAllByCwd returns clients for every discovered session whose cwd
+// matches workspace, except the one at exclude, ordered by socket address so
+// merging them is reproducible from run to run. The caller closes them.
+func DiscoverAllByCwd(ctx context.Context, workspace, exclude string) []*Client {
+	var clients []*Client
+	seen := map[string]bool{exclude: true}
+	candidates := discoverSocketCandidates()
+	slices.Sort(candidates)
+	for _, addr := range candidates {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		cli, cwd, err := connectCandidate(ctx, addr)
 		if err != nil {
-			logger.Warnf("nvim discovery: failed to getcwd for %s: %v", addr, err)
-			_ = n.Close()
 			continue
 		}
-		if cwd == workspace {
-			logger.Infof("nvim discovery: matched workspace cwd=%s at %s", cwd, addr)
-			return cli, nil
+		if cwd != workspace {
+			cli.Close()
+			continue
+		}
+		cli.workspace = workspace
+		logger.Infof("nvim discovery: found another session for %s at %s", workspace, addr)
+		clients = append(clients, cli)
+	}
+	return clients
+}
+
+// connectCandidate connects to the socket at addr and returns the client with
+// the session's cwd. The client has no workspace yet, so probing a socket
+// that drops the connection never reconnects; callers set it once the cwd
+// matches.
+func connectCandidate(ctx context.Context, addr string) (*Client, string, error) {
+	logger.Infof("nvim discovery: trying %s", addr)
+	err := retry(ctx, DialAttempts, DialBaseDelay, func() error {
+		conn, err := net.DialTimeout("unix", addr, 1*time.Second)
+		if err != nil {
+			// A missing socket file is stale, not busy
+			if errors.Is(err, os.ErrNotExist) {
+				return permanent(err)
+			}
+			return err
 		}
+		return conn.Close()
+	})
+	if err != nil {
+		logger.Warnf("nvim discovery: dial timeout or failed for %s: %v", addr, err)
+		return nil, "", err
+	}
+
+	n, err := dial(addr)
+	if err != nil {
+		logger.Warnf("nvim discovery: full dial failed for %s: %v", addr, err)
+		return nil, "", err
+	}
+	cli := &Client{NV: n, addr: addr, method: ConnectDiscovery}
+	getcwdCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	cwd, err := GetCwd(getcwdCtx, cli)
+	if err != nil {
+		logger.Warnf("nvim discovery: failed to getcwd for %s: %v", addr, err)
 		_ = n.Close()
+		return nil, "", err
 	}
-	return nil, errors.New("no Neovim sessions found matching workspace cwd")
+	return cli, cwd, nil
+}
+
+// Session is a reachable Neovim session.
+type Session struct {
+	Addr string `json:"addr"`
+	Cwd  string `json:"cwd"`
+}
+
+// DiscoverSessions returns every reachable session, the one named by
+// NVIM_LISTEN_ADDRESS or $NVIM first.
+func DiscoverSessions(ctx context.Context) []Session {
+	var sessions []Session
+	seen := make(map[string]bool)
+	if c, err := ConnectFromEnv(ctx); err == nil {
+		if cwd, err := GetCwd(ctx, c); err == nil {
+			sessions = append(sessions, Session{Addr: c.addr, Cwd: cwd})
+		}
+		seen[c.addr] = true
+		c.Close()
+	}
+	for _, addr := range discoverSocketCandidates() {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		cli, cwd, err := connectCandidate(ctx, addr)
+		if err != nil {
+			continue
+		}
+		cli.Close()
+		sessions = append(sessions, Session{Addr: addr, Cwd: cwd})
+	}
+	return sessions
 }
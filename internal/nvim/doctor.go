@@ -0,0 +1,111 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// MinNeovimVersion is the oldest Neovim release the Lua helpers support.
+var MinNeovimVersion = [2]int{0, 11}
+
+// Check is the outcome of a single doctor check.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+	// Hint suggests how to fix a failed check.
+	Hint string `json:"hint,omitempty"`
+}
+
+// Doctor runs the setup checks for workspace, from the environment through to
+// a diagnostics round-trip in the matching Neovim session. Checks that need a
+// session fail with a pointer to the session check when none was found.
+func Doctor(ctx context.Context, workspace string) []Check {
+	var checks []Check
+
+	switch {
+	case os.Getenv("NVIM_LISTEN_ADDRESS") != "":
+		checks = append(checks, Check{Name: "environment", OK: true, Detail: "NVIM_LISTEN_ADDRESS=" + os.Getenv("NVIM_LISTEN_ADDRESS")})
+	case os.Getenv("NVIM") != "":
+		checks = append(checks, Check{Name: "environment", OK: true, Detail: "NVIM=" + os.Getenv("NVIM")})
+	default:
+		checks = append(checks, Check{Name: "environment", OK: true, Detail: "NVIM_LISTEN_ADDRESS and NVIM are not set, relying on socket discovery"})
+	}
+
+	if path, err := exec.LookPath("git"); err == nil {
+		checks = append(checks, Check{Name: "git", OK: true, Detail: path})
+	} else {
+		checks = append(checks, Check{Name: "git", Detail: err.Error(), Hint: "install git and make sure it is on the server's PATH; changed-file detection and lint-diff need it"})
+	}
+
+	candidates := discoverSocketCandidates()
+	if len(candidates) > 0 {
+		checks = append(checks, Check{Name: "sockets", OK: true, Detail: fmt.Sprintf("%d candidate sockets found", len(candidates))})
+	} else {
+		checks = append(checks, Check{Name: "sockets", Detail: "no Neovim sockets found", Hint: "start Neovim, or export NVIM_LISTEN_ADDRESS (e.g. nvim --listen /tmp/nvim.sock)"})
+	}
+
+	c, err := ConnectWorkspace(ctx, workspace)
+	if err != nil {
+		checks = append(checks, Check{Name: "session", Detail: err.Error(), Hint: "open Neovim in " + workspace + " (or :cd into it) so its cwd matches the workspace"})
+		skipped := "skipped: no matching session"
+		return append(checks,
+			Check{Name: "neovim version", Detail: skipped},
+			Check{Name: "lsp clients", Detail: skipped},
+			Check{Name: "diagnostics", Detail: skipped},
+		)
+	}
+	defer c.Close()
+	conn := c.Connection()
+	checks = append(checks, Check{Name: "session", OK: true, Detail: fmt.Sprintf("cwd matches %s (via %s at %s)", workspace, conn.Method, conn.Addr)})
+
+	version, err := neovimVersion(ctx, c)
+	switch {
+	case err != nil:
+		checks = append(checks, Check{Name: "neovim version", Detail: fmt.Sprintf("failed to read version: %v", err), Hint: "upgrade Neovim"})
+	case versionSupported(version):
+		checks = append(checks, Check{Name: "neovim version", OK: true, Detail: fmt.Sprintf("%d.%d.%d", version[0], version[1], version[2])})
+	default:
+		checks = append(checks, Check{
+			Name:   "neovim version",
+			Detail: fmt.Sprintf("%d.%d.%d is older than %d.%d", version[0], version[1], version[2], MinNeovimVersion[0], MinNeovimVersion[1]),
+			Hint:   fmt.Sprintf("upgrade Neovim to %d.%d or newer", MinNeovimVersion[0], MinNeovimVersion[1]),
+		})
+	}
+
+	clients, err := ListClients(ctx, c)
+	switch {
+	case err != nil:
+		checks = append(checks, Check{Name: "lsp clients", Detail: err.Error(), Hint: "check :checkhealth vim.lsp in Neovim"})
+	case len(clients) == 0:
+		checks = append(checks, Check{Name: "lsp clients", Detail: "no LSP clients are running", Hint: "open a source file so a language server attaches, and check :checkhealth vim.lsp"})
+	default:
+		checks = append(checks, Check{Name: "lsp clients", OK: true, Detail: fmt.Sprintf("%d clients running", len(clients))})
+	}
+
+	if count, err := callResult(ctx, c, func(n *nv.Nvim, out *int) error { return n.ExecLua("return #vim.diagnostic.get()", out) }); err != nil {
+		checks = append(checks, Check{Name: "diagnostics", Detail: err.Error(), Hint: "check :messages in Neovim for Lua errors"})
+	} else {
+		checks = append(checks, Check{Name: "diagnostics", OK: true, Detail: fmt.Sprintf("vim.diagnostic.get returned %d diagnostics", count)})
+	}
+	return checks
+}
+
+// ConnectWorkspace attaches to the session whose cwd is workspace, preferring
+// NVIM_LISTEN_ADDRESS (or $NVIM) over discovery.
+func ConnectWorkspace(ctx context.Context, workspace string) (*Client, error) {
+	c, err := ConnectFromEnv(ctx)
+	if err == nil {
+		cwd, cwdErr := GetCwd(ctx, c)
+		if cwdErr == nil && cwd == workspace {
+			c.workspace = workspace
+			return c, nil
+		}
+		c.Close()
+	}
+	return DiscoverAndConnectByCwd(ctx, workspace)
+}
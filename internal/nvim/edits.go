@@ -0,0 +1,90 @@
+package nvim
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// otherGroup heads the diagnostics overlapping no edit in FormatGroupedByEdit.
+const otherGroup = "other"
+
+// Edit is a range of lines changed by an edit, to attribute diagnostics to.
+// Lines are 1-based and inclusive; EndLine defaults to StartLine.
+type Edit struct {
+	File      string `json:"file"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine,omitempty"`
+}
+
+// String describes the edit as "file:start-end", or "file:line" for a
+// single line.
+func (e Edit) String() string {
+	if e.EndLine <= e.StartLine {
+		return fmt.Sprintf("%s:%d", e.File, e.StartLine)
+	}
+	return fmt.Sprintf("%s:%d-%d", e.File, e.StartLine, e.EndLine)
+}
+
+// validate checks that the edit names an absolute file and a valid range.
+func (e Edit) validate() error {
+	if !filepath.IsAbs(e.File) {
+		return fmt.Errorf("invalid edit %s: file must be an absolute path", e)
+	}
+	if e.StartLine < 1 || (e.EndLine != 0 && e.EndLine < e.StartLine) {
+		return fmt.Errorf("invalid edit %s: want 1 <= startLine <= endLine", e)
+	}
+	return nil
+}
+
+// overlaps reports whether d is in the edited file and its lines intersect
+// the edit. Relative paths of either are taken relative to root.
+func (e Edit) overlaps(d Diagnostic, root string) bool {
+	abs := func(file string) string {
+		if filepath.IsAbs(file) {
+			return filepath.Clean(file)
+		}
+		return filepath.Join(root, file)
+	}
+	end := max(e.EndLine, e.StartLine)
+	return abs(e.File) == abs(d.File) && d.Line <= end && max(d.EndLine, d.Line) >= e.StartLine
+}
+
+// FormatGroupedByEdit renders the diagnostics under a
+// "== edit N: file:start-end (count) ==" header per edit of the report, in
+// the order the edits were given, to show which change introduced which
+// problem. A diagnostic goes under the first edit its lines overlap; those
+// overlapping none are grouped under "other". Edits without diagnostics are
+// kept, with a count of 0, to show they are clean. The report sections
+// follow as in FormatText.
+func FormatGroupedByEdit(r *Report) string {
+	groups := make([][]Diagnostic, len(r.Edits)+1)
+	for _, d := range r.Diagnostics {
+		i := len(r.Edits)
+		for j, e := range r.Edits {
+			if e.overlaps(d, r.Workspace) {
+				i = j
+				break
+			}
+		}
+		groups[i] = append(groups[i], d)
+	}
+
+	var lines []string
+	for i, group := range groups {
+		header := fmt.Sprintf("== %s (%d) ==", otherGroup, len(group))
+		if i < len(r.Edits) {
+			e := r.Edits[i]
+			e.File = r.displayPath(e.File)
+			header = fmt.Sprintf("== edit %d: %s (%d) ==", i+1, e, len(group))
+		} else if len(group) == 0 {
+			continue
+		}
+		section := []string{header}
+		for _, d := range group {
+			section = append(section, r.display(d).String())
+		}
+		lines = appendSection(lines, section...)
+	}
+	return strings.Join(appendSections(lines, r), "\n")
+}
@@ -0,0 +1,41 @@
+package nvim
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ExportJSON writes the report as JSON to path for tools watching the file.
+// The file is replaced atomically by renaming a temporary file in the same
+// directory, so readers never see a partial write.
+func ExportJSON(r *Report, path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("export path %s must be absolute", path)
+	}
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("export directory %s does not exist", dir)
+	}
+	data, err := FormatJSON(r)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(data + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp creates the file private to the user
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,147 @@
+package nvim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/neovim/go-client/msgpack/rpc"
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// fakeBuffer is a buffer of a fakeSession with its vim.diagnostic items.
+type fakeBuffer struct {
+	name    string
+	clients []string
+	diags   []map[string]any
+}
+
+// fakeSession answers the RPC calls of the diagnostics collection like a
+// Neovim session with the given buffers, numbered from 1, and diagnostic
+// namespace to client names.
+type fakeSession struct {
+	buffers    []fakeBuffer
+	namespaces map[string]string
+	// progress answers lsp_busy.lua, if set.
+	progress func() lspStatus
+	// changed are the workspace's changed files with a filetype. With no
+	// LSP client running, filter_changed_files.lua only keeps them when
+	// asked to keep any filetype.
+	changed []string
+	// opened collects the files refresh_diagnostics.lua loaded; an LSP
+	// client counts as attached once one is.
+	opened *[]string
+	// noVimJSON makes the session lack vim.json like a very old build.
+	noVimJSON bool
+}
+
+// newFakeClient serves s over an in-memory msgpack-RPC connection and returns
+// a Client connected to it.
+func newFakeClient(t *testing.T, addr string, s fakeSession) *Client {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	server, err := rpc.NewEndpoint(serverConn, serverConn, serverConn, rpc.WithLogf(t.Logf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(server.Register("nvim_call_function", func(fn string, args []any) (any, error) {
+		if fn != "nvim_list_bufs" {
+			return nil, fmt.Errorf("fake session: unexpected function %s", fn)
+		}
+		bufs := make([]int, len(s.buffers))
+		for i := range s.buffers {
+			bufs[i] = i + 1
+		}
+		return bufs, nil
+	}))
+	must(server.Register("nvim_exec_lua", s.execLua))
+	go server.Serve()
+
+	n, err := nv.New(clientConn, clientConn, clientConn, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go n.Serve()
+	t.Cleanup(func() {
+		n.Close()
+		server.Close()
+	})
+	return &Client{NV: n, addr: addr}
+}
+
+// execLua recognises the Lua chunks of the collection and answers them from
+// the session's state.
+func (s fakeSession) execLua(code string, args []any) (any, error) {
+	encode := func(v any) (any, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+	var bufnr int
+	switch {
+	case code == bufferInfoLua:
+		infos := make([]bufferInfo, len(s.buffers))
+		for i, b := range s.buffers {
+			infos[i] = bufferInfo{Bufnr: i + 1, Name: b.name, Clients: b.clients, Lines: 100, Diagnostics: len(b.diags)}
+		}
+		return encode(infos)
+	case code == namespaceClientsLua:
+		return encode(s.namespaces)
+	case code == lspBusyLua && s.progress != nil:
+		return encode(s.progress())
+	case code == filterLua:
+		result := luaFilterResult{Filtered: []string{}, OrigCount: len(s.changed)}
+		if anyFiletype, _ := args[2].(bool); anyFiletype {
+			result.Filtered = s.changed
+		}
+		result.FilteredCount = len(result.Filtered)
+		return encode(result)
+	case code == refreshLua && s.opened != nil:
+		for _, f := range args[0].([]any) {
+			*s.opened = append(*s.opened, f.(string))
+		}
+		return map[string]any{}, nil
+	case strings.HasPrefix(code, "return vim.wait(") && s.opened != nil:
+		return len(*s.opened) > 0, nil
+	case code == "return vim.json ~= nil and vim.json.encode ~= nil":
+		return !s.noVimJSON, nil
+	case strings.HasPrefix(code, "local d = vim.diagnostic.get("):
+		if _, err := fmt.Sscanf(code, "local d = vim.diagnostic.get(%d)", &bufnr); err != nil {
+			return nil, err
+		}
+		if diags := s.buffers[bufnr-1].diags; len(diags) > 0 {
+			return diags, nil
+		}
+		return nil, nil
+	case strings.HasPrefix(code, "return vim.json.encode(vim.diagnostic.get("):
+		if s.noVimJSON {
+			return nil, fmt.Errorf("attempt to index field 'json' (a nil value)")
+		}
+		if _, err := fmt.Sscanf(code, "return vim.json.encode(vim.diagnostic.get(%d))", &bufnr); err != nil {
+			return nil, err
+		}
+		diags := s.buffers[bufnr-1].diags
+		if diags == nil {
+			diags = []map[string]any{}
+		}
+		return encode(diags)
+	}
+	return nil, fmt.Errorf("fake session: unexpected Lua %.60q", code)
+}
+
+// fakeDiagnostic returns a vim.diagnostic item with 0-based lnum and col.
+func fakeDiagnostic(lnum, col, severity, namespace int, message string) map[string]any {
+	return map[string]any{
+		"lnum":      lnum,
+		"col":       col,
+		"severity":  severity,
+		"namespace": namespace,
+		"message":   message,
+	}
+}
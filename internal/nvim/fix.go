@@ -0,0 +1,123 @@
+package nvim
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// MaxFixIterations bounds the fix and re-check rounds of FixAndVerify, since
+// a fix may introduce diagnostics whose fixes undo it.
+const MaxFixIterations = 3
+
+// FixResult is the outcome of FixAndVerify.
+type FixResult struct {
+	// Fixed describes each applied code action as "file:line: title".
+	Fixed []string `json:"fixed"`
+	// Iterations is the number of fix rounds that applied something.
+	Iterations int `json:"iterations"`
+	// Report holds the diagnostics remaining after the last round.
+	Report *Report `json:"report"`
+}
+
+// FixAndVerify collects diagnostics, applies the available fixes and collects
+// again, for up to iterations rounds (capped at MaxFixIterations) or until a
+// round fixes nothing. Per file, a source.fixAll action is applied when
+// offered; otherwise each diagnostic gets its quickfix, if the server marks
+// one as preferred or offers exactly one. Quickfixes are applied bottom-up so
+// earlier positions stay valid.
+func FixAndVerify(ctx context.Context, c *Client, opts CollectOptions, iterations int) (*FixResult, error) {
+	if iterations <= 0 || iterations > MaxFixIterations {
+		iterations = MaxFixIterations
+	}
+	result := &FixResult{Fixed: []string{}}
+	for {
+		report, err := GatherDiagnostics(ctx, c, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Report = report
+		if len(report.Diagnostics) == 0 || result.Iterations == iterations {
+			return result, nil
+		}
+		fixed := fixDiagnostics(ctx, c, report.Diagnostics)
+		if len(fixed) == 0 {
+			return result, nil
+		}
+		result.Iterations++
+		result.Fixed = append(result.Fixed, fixed...)
+		logger.Infof("nvim: fix round %d applied %d fixes", result.Iterations, len(fixed))
+	}
+}
+
+// fixDiagnostics applies the fixes for diags and describes what it applied.
+func fixDiagnostics(ctx context.Context, c *Client, diags []Diagnostic) []string {
+	byFile := make(map[string][]Diagnostic)
+	var files []string
+	for _, d := range diags {
+		if _, ok := byFile[d.File]; !ok {
+			files = append(files, d.File)
+		}
+		byFile[d.File] = append(byFile[d.File], d)
+	}
+
+	var fixed []string
+	for _, file := range files {
+		if title, ok := applyFix(ctx, c, file, Position{Line: 1, Col: 1}, "source.fixAll"); ok {
+			fixed = append(fixed, fmt.Sprintf("%s: %s", file, title))
+			continue
+		}
+		fileDiags := byFile[file]
+		slices.SortFunc(fileDiags, func(a, b Diagnostic) int {
+			return cmp.Or(cmp.Compare(b.Line, a.Line), cmp.Compare(b.Col, a.Col))
+		})
+		for _, d := range fileDiags {
+			if title, ok := applyFix(ctx, c, file, Position{Line: d.Line, Col: d.Col}, "quickfix"); ok {
+				fixed = append(fixed, fmt.Sprintf("%s:%d: %s", file, d.Line, title))
+			}
+		}
+	}
+	return fixed
+}
+
+// applyFix applies the unambiguous code action of kind at pos, returning its
+// title. Failures are logged since other fixes may still apply.
+func applyFix(ctx context.Context, c *Client, file string, pos Position, kind string) (string, bool) {
+	listed, err := CodeActions(ctx, c, file, pos, kind, 0, "")
+	if err != nil {
+		logger.Warnf("nvim: failed to list %s actions for %s: %v", kind, file, err)
+		return "", false
+	}
+	index := 0
+	var candidates []int
+	for i, a := range listed.Actions {
+		// Servers may ignore the kind filter
+		if a.Kind != kind && !strings.HasPrefix(a.Kind, kind+".") {
+			continue
+		}
+		if a.Preferred {
+			index = i + 1
+			break
+		}
+		candidates = append(candidates, i+1)
+	}
+	if index == 0 && len(candidates) == 1 {
+		index = candidates[0]
+	}
+	if index == 0 {
+		return "", false
+	}
+	applied, err := CodeActions(ctx, c, file, pos, kind, index, "")
+	if err != nil {
+		logger.Warnf("nvim: failed to apply %s action for %s: %v", kind, file, err)
+		return "", false
+	}
+	if len(applied.Unsaved) > 0 {
+		logger.Warnf("nvim: fix left unsaved buffers: %v", applied.Unsaved)
+	}
+	return applied.Applied, true
+}
@@ -0,0 +1,81 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+//go:embed lua/fixable_probe.lua
+var fixableProbeLua string
+
+// MaxFixableProbes bounds the code action probes of CollectOptions.CheckFixable.
+const MaxFixableProbes = 50
+
+// markFixable sets Fixable on the diagnostics with a code action at their
+// position. The probes run concurrently in a single call, for at most
+// MaxFixableProbes diagnostics; the rest stay unmarked.
+func markFixable(ctx context.Context, c *Client, diags []Diagnostic) error {
+	n := min(len(diags), MaxFixableProbes)
+	if n == 0 {
+		return nil
+	}
+	if n < len(diags) {
+		c.warnf("probing code actions for the first %d of %d diagnostics", n, len(diags))
+	}
+	probes := make([]map[string]any, n)
+	for i, d := range diags[:n] {
+		probes[i] = map[string]any{"file": d.File, "row": d.Line - 1, "col": d.Col - 1}
+	}
+
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(fixableProbeLua, out, probes, luaTimeout(ctx, RequestTimeout))
+	})
+	if err != nil {
+		return err
+	}
+	var fixable []bool
+	if err := json.Unmarshal([]byte(jsonStr), &fixable); err != nil {
+		return fmt.Errorf("invalid fixable probe result: %w", err)
+	}
+	for i := range min(len(fixable), n) {
+		// Keep a FixableHints mark the probe cannot confirm at the position
+		diags[i].Fixable = diags[i].Fixable || fixable[i]
+	}
+	return nil
+}
+
+// fixableHint reports whether the LSP data payload of a vim.diagnostic item
+// says that a quick fix exists, for CollectOptions.FixableHints. The payload
+// is server specific; recognised are a true "fixable" flag and non-empty
+// "fix", "fixes" or "edits" members, as sent by ruff ("edits") and Deno's
+// linter ("fixes").
+func fixableHint(item map[string]any) bool {
+	userData, _ := item["user_data"].(map[string]any)
+	lsp, _ := userData["lsp"].(map[string]any)
+	data, _ := lsp["data"].(map[string]any)
+	if fixable, ok := data["fixable"].(bool); ok {
+		return fixable
+	}
+	for _, key := range []string{"fix", "fixes", "edits"} {
+		switch v := data[key].(type) {
+		case []any:
+			if len(v) > 0 {
+				return true
+			}
+		case map[string]any:
+			if len(v) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
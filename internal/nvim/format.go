@@ -0,0 +1,242 @@
+package nvim
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Output formats for collected diagnostics.
+const (
+	// OutputText renders one compiler-style line per diagnostic
+	OutputText = "text"
+	// OutputJSON renders a Report as JSON
+	OutputJSON = "json"
+	// OutputGitHub renders GitHub Actions workflow annotation commands
+	OutputGitHub = "github"
+	// OutputMarkdown renders a markdown table
+	OutputMarkdown = "markdown"
+	// OutputGroupedByCode renders the diagnostics grouped by source and code
+	OutputGroupedByCode = "grouped-by-code"
+	// OutputGroupedBySeverity renders the diagnostics grouped by severity
+	OutputGroupedBySeverity = "grouped-by-severity"
+	// OutputGroupedByEdit renders the diagnostics grouped by the edit they
+	// overlap
+	OutputGroupedByEdit = "grouped-by-edit"
+	// OutputSummaryLine renders only the counts per severity on one line
+	OutputSummaryLine = "summary-line"
+	// OutputLSP renders the diagnostics as LSP Diagnostic objects per file URI
+	OutputLSP = "lsp"
+)
+
+// OutputFormats lists the supported output formats.
+var OutputFormats = []string{OutputText, OutputJSON, OutputGitHub, OutputMarkdown, OutputGroupedByCode, OutputGroupedBySeverity, OutputGroupedByEdit, OutputSummaryLine, OutputLSP}
+
+// Meta records the state diagnostics were collected against.
+type Meta struct {
+	Cwd string `json:"cwd"`
+	// Commit is the git HEAD commit, empty outside a git repository.
+	Commit string `json:"commit,omitempty"`
+	// Connection is how the Neovim session was found.
+	Connection Connection `json:"connection"`
+}
+
+// Report is the structured result of a diagnostics collection.
+type Report struct {
+	// Meta is set when CollectOptions.IncludeMeta is true.
+	Meta        *Meta        `json:"meta,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// SkippedUnsaved lists files whose buffers were not reloaded because they
+	// have unsaved changes; their diagnostics may be stale.
+	SkippedUnsaved []string `json:"skippedUnsaved,omitempty"`
+	// Omitted summarizes diagnostics left out to fit the limit or budget.
+	Omitted []OmittedGroup `json:"omitted,omitempty"`
+	// Quickfix reports the outcome of exporting to the quickfix list.
+	Quickfix string `json:"quickfix,omitempty"`
+	// Gate is the lint gate outcome, set when fail rules were given.
+	Gate *GateResult `json:"gate,omitempty"`
+	// BuffersChecked is the number of buffers in scope that diagnostics were
+	// read from, and FilesRefreshed the number of files reloaded and
+	// notified beforehand.
+	BuffersChecked int `json:"buffersChecked"`
+	FilesRefreshed int `json:"filesRefreshed"`
+	// FileClients lists the clients attached to each requested file, set
+	// when CollectOptions.ReportFileClients is true.
+	FileClients []FileClients `json:"fileClients,omitempty"`
+	// OverflowPath is the file the full set of diagnostics was written to
+	// because they exceeded CollectOptions.OverflowThreshold.
+	OverflowPath string `json:"overflowPath,omitempty"`
+	// Partial lists the LSP clients that were still busy when their
+	// CollectOptions.ClientTimeout expired, whose diagnostics may be
+	// incomplete.
+	Partial []string `json:"partial,omitempty"`
+	// Warnings lists operational problems that may make the result
+	// incomplete, such as skipped files or failed refreshes.
+	Warnings []string `json:"warnings,omitempty"`
+	// Columns selects and orders the fields of the text output, see
+	// TextColumns. Empty means the default layout of Diagnostic.String.
+	Columns []string `json:"-"`
+	// Multiline and MessageSeparator control how messages spanning several
+	// lines are rendered in text output, see displayMessage.
+	Multiline        string `json:"-"`
+	MessageSeparator string `json:"-"`
+	// Edits are the edits diagnostics are attributed to in
+	// OutputGroupedByEdit.
+	Edits []Edit `json:"-"`
+	// Workspace is the root that relative output paths are computed from.
+	Workspace string `json:"-"`
+	// StripPrefix is removed from the start of rendered paths, see
+	// displayPath.
+	StripPrefix string `json:"-"`
+}
+
+// Format renders the report in the given output format.
+func Format(r *Report, format string) (string, error) {
+	switch format {
+	case OutputJSON:
+		return FormatJSON(r)
+	case OutputGitHub:
+		return FormatGitHub(r), nil
+	case OutputMarkdown:
+		return FormatMarkdown(r), nil
+	case OutputGroupedByCode:
+		return FormatGroupedByCode(r), nil
+	case OutputGroupedBySeverity:
+		return FormatGroupedBySeverity(r), nil
+	case OutputGroupedByEdit:
+		return FormatGroupedByEdit(r), nil
+	case OutputSummaryLine:
+		return FormatSummaryLine(r), nil
+	case OutputLSP:
+		return FormatLSP(r)
+	default:
+		return FormatText(r), nil
+	}
+}
+
+// FormatText renders an optional metadata header and the diagnostics one per
+// line, followed by the report sections (see appendSections) and a trailing
+// warnings section.
+func FormatText(r *Report) string {
+	lines := make([]string, 0, len(r.Diagnostics))
+	if r.Meta != nil {
+		lines = append(lines, "# cwd: "+r.Meta.Cwd)
+		if r.Meta.Commit != "" {
+			lines = append(lines, "# commit: "+r.Meta.Commit)
+		}
+		lines = append(lines, fmt.Sprintf("# connection: %s (%s)", r.Meta.Connection.Method, r.Meta.Connection.Addr))
+	}
+	for _, d := range r.Diagnostics {
+		d = r.display(d)
+		if len(r.Columns) > 0 {
+			lines = append(lines, d.columns(r.Columns))
+		} else {
+			lines = append(lines, d.String())
+		}
+	}
+	lines = appendSections(lines, r)
+	if len(r.Warnings) > 0 {
+		lines = appendSection(lines, "--- warnings ---")
+		for _, w := range r.Warnings {
+			lines = append(lines, "  "+w)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendSections appends the lint gate outcome, the diagnostics omitted to fit
+// the limits, the files skipped due to unsaved changes, the clients of the
+// requested files and the quickfix export, each as its own section.
+func appendSections(lines []string, r *Report) []string {
+	if r.Gate != nil {
+		section := []string{r.Gate.summary()}
+		if r.Gate.Failed {
+			section[0] += ":"
+		}
+		for _, t := range r.Gate.Tripped {
+			section = append(section, "  "+t)
+		}
+		lines = appendSection(lines, section...)
+	}
+	if len(r.Omitted) > 0 {
+		total := 0
+		for _, g := range r.Omitted {
+			total += g.Count
+		}
+		header := fmt.Sprintf("omitted %d less severe diagnostics to fit the limit:", total)
+		if r.OverflowPath != "" {
+			header = fmt.Sprintf("omitted %d less severe diagnostics, all %d are in %s:", total, total+len(r.Diagnostics), r.OverflowPath)
+		}
+		section := []string{header}
+		for _, g := range r.Omitted {
+			section = append(section, fmt.Sprintf("  %s: %d %s", r.displayPath(g.File), g.Count, g.Severity))
+		}
+		lines = appendSection(lines, section...)
+	}
+	if len(r.SkippedUnsaved) > 0 {
+		section := []string{"skipped reloading (unsaved changes, diagnostics may be stale):"}
+		for _, f := range r.SkippedUnsaved {
+			section = append(section, "  "+r.displayPath(f))
+		}
+		lines = appendSection(lines, section...)
+	}
+	if len(r.FileClients) > 0 {
+		section := []string{"LSP clients per requested file:"}
+		for _, fc := range r.FileClients {
+			switch {
+			case !fc.Loaded:
+				section = append(section, fmt.Sprintf("  %s: no buffer", r.displayPath(fc.File)))
+			case len(fc.Clients) == 0:
+				section = append(section, fmt.Sprintf("  %s: 0 clients (file type not supported?)", r.displayPath(fc.File)))
+			default:
+				section = append(section, fmt.Sprintf("  %s: %d clients (%s)", r.displayPath(fc.File), len(fc.Clients), strings.Join(fc.Clients, ", ")))
+			}
+		}
+		lines = appendSection(lines, section...)
+	}
+	if r.Quickfix != "" {
+		lines = appendSection(lines, r.Quickfix)
+	}
+	return lines
+}
+
+// relPath returns file relative to the report's workspace, or file itself if
+// it lies outside.
+func (r *Report) relPath(file string) string {
+	if r.Workspace == "" {
+		return file
+	}
+	if rel, err := filepath.Rel(r.Workspace, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
+
+// appendSection appends section to lines, separated by a blank line.
+func appendSection(lines []string, section ...string) []string {
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	return append(lines, section...)
+}
+
+// FormatJSON renders the report as JSON.
+func FormatJSON(r *Report) (string, error) {
+	report := *r
+	if report.Diagnostics == nil {
+		report.Diagnostics = []Diagnostic{}
+	}
+	if r.StripPrefix != "" {
+		report.Diagnostics = slices.Clone(report.Diagnostics)
+		for i := range report.Diagnostics {
+			report.Diagnostics[i].File = r.displayPath(report.Diagnostics[i].File)
+		}
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,88 @@
+package nvim
+
+import (
+	"fmt"
+)
+
+// FailRule fails the lint gate when more than MaxCount diagnostics from Source
+// are at least as severe as Severity. An empty or "*" Source matches any
+// source.
+type FailRule struct {
+	Source   string `json:"source,omitempty"`
+	Severity string `json:"severity"`
+	MaxCount int    `json:"maxCount,omitempty"`
+}
+
+// String describes the rule, e.g. "staticcheck: > 0 error".
+func (r FailRule) String() string {
+	source := r.Source
+	if source == "" {
+		source = "*"
+	}
+	return fmt.Sprintf("%s: > %d %s", source, r.MaxCount, r.Severity)
+}
+
+// GateResult is the outcome of evaluating fail rules. It is reported whether
+// or not the gate failed, so a passing run can still be logged.
+type GateResult struct {
+	Failed bool `json:"failed"`
+	// Tripped describes each rule that failed the gate with its actual count.
+	Tripped []string `json:"tripped,omitempty"`
+	// Rules lists the outcome of every rule, in order.
+	Rules []RuleOutcome `json:"rules"`
+	// Counts are the evaluated diagnostics per severity, and
+	// HighestSeverity the most severe among them, empty when there are none.
+	Counts          SeverityCounts `json:"counts"`
+	HighestSeverity string         `json:"highestSeverity,omitempty"`
+}
+
+// RuleOutcome is the result of one fail rule.
+type RuleOutcome struct {
+	Rule   string `json:"rule"`
+	Count  int    `json:"count"`
+	Passed bool   `json:"passed"`
+}
+
+// summary describes the outcome in one line, e.g. "lint gate passed
+// (highest severity warning, E:0 W:3 I:1 H:0)".
+func (g *GateResult) summary() string {
+	status := "passed"
+	if g.Failed {
+		status = "failed"
+	}
+	highest := g.HighestSeverity
+	if highest == "" {
+		highest = "none"
+	}
+	return fmt.Sprintf("lint gate %s (highest severity %s, %s)", status, highest, g.Counts)
+}
+
+// failOnErrorsRule is the rule implied by CollectOptions.FailOnErrors.
+var failOnErrorsRule = FailRule{Source: "*", Severity: "error"}
+
+// evaluateGate checks diags against rules.
+func evaluateGate(diags []Diagnostic, rules []FailRule) *GateResult {
+	result := &GateResult{}
+	for _, d := range diags {
+		result.Counts.add(d.Severity, 1)
+	}
+	result.HighestSeverity = result.Counts.highest()
+	for _, rule := range rules {
+		count := 0
+		for _, d := range diags {
+			if rule.Source != "" && rule.Source != "*" && rule.Source != d.Source {
+				continue
+			}
+			if severityRank(d.Severity) <= severityRank(rule.Severity) {
+				count++
+			}
+		}
+		passed := count <= rule.MaxCount
+		result.Rules = append(result.Rules, RuleOutcome{Rule: rule.String(), Count: count, Passed: passed})
+		if !passed {
+			result.Failed = true
+			result.Tripped = append(result.Tripped, fmt.Sprintf("%s (found %d)", rule, count))
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package nvim
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEvaluateGate(t *testing.T) {
+	diags := []Diagnostic{
+		{Severity: "error", Source: "compiler"},
+		{Severity: "warning", Source: "staticcheck"},
+		{Severity: "warning", Source: "staticcheck"},
+		{Severity: "hint", Source: "staticcheck"},
+	}
+	tests := []struct {
+		name        string
+		diags       []Diagnostic
+		rules       []FailRule
+		wantFailed  bool
+		wantCounts  []int
+		wantTripped []string
+	}{
+		{"no rules", diags, nil, false, nil, nil},
+		{"no diagnostics", nil, []FailRule{failOnErrorsRule}, false, []int{0}, nil},
+		{"errors fail", diags, []FailRule{failOnErrorsRule}, true, []int{1}, []string{"*: > 0 error (found 1)"}},
+		{"count at max passes", diags, []FailRule{{Source: "staticcheck", Severity: "warning", MaxCount: 2}}, false, []int{2}, nil},
+		{"count above max fails", diags, []FailRule{{Source: "staticcheck", Severity: "hint", MaxCount: 2}}, true, []int{3},
+			[]string{"staticcheck: > 2 hint (found 3)"}},
+		{"severity includes more severe", diags, []FailRule{{Severity: "warning", MaxCount: 3}}, false, []int{3}, nil},
+		{"other source ignored", diags, []FailRule{{Source: "compiler", Severity: "warning"}}, true, []int{1},
+			[]string{"compiler: > 0 warning (found 1)"}},
+		{"unknown source counts nothing", diags, []FailRule{{Source: "eslint", Severity: "hint"}}, false, []int{0}, nil},
+		{"every rule evaluated", diags, []FailRule{failOnErrorsRule, {Source: "staticcheck", Severity: "warning", MaxCount: 5}}, true, []int{1, 2},
+			[]string{"*: > 0 error (found 1)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateGate(tt.diags, tt.rules)
+			if got.Failed != tt.wantFailed {
+				t.Errorf("failed = %v, want %v", got.Failed, tt.wantFailed)
+			}
+			var counts []int
+			for i, r := range got.Rules {
+				counts = append(counts, r.Count)
+				if r.Passed == (r.Count > tt.rules[i].MaxCount) {
+					t.Errorf("rule %s passed = %v with count %d", r.Rule, r.Passed, r.Count)
+				}
+			}
+			if !slices.Equal(counts, tt.wantCounts) {
+				t.Errorf("rule counts = %v, want %v", counts, tt.wantCounts)
+			}
+			if !slices.Equal(got.Tripped, tt.wantTripped) {
+				t.Errorf("tripped = %q, want %q", got.Tripped, tt.wantTripped)
+			}
+		})
+	}
+
+	got := evaluateGate(diags, nil)
+	if want := (SeverityCounts{Error: 1, Warning: 2, Hint: 1}); got.Counts != want || got.HighestSeverity != "error" {
+		t.Errorf("counts = %+v, highest %q, want %+v, error", got.Counts, got.HighestSeverity, want)
+	}
+	if got := evaluateGate(nil, nil); got.HighestSeverity != "" {
+		t.Errorf("highest severity without diagnostics = %q, want empty", got.HighestSeverity)
+	}
+}
@@ -0,0 +1,75 @@
+package nvim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runGit runs git with args in dir and returns its trimmed stdout.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitPaths runs the git subcommand listing paths, args[0], with -z and the
+// rest of args, and returns the listed paths. core.quotepath is disabled and
+// the output is split on NUL bytes, so paths with spaces, newlines or
+// non-ASCII characters come back verbatim instead of quoted and escaped.
+func gitPaths(ctx context.Context, dir string, args ...string) ([]string, error) {
+	args = append([]string{"-c", "core.quotepath=false", args[0], "-z"}, args[1:]...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	var paths []string
+	for rel := range strings.SplitSeq(stdout.String(), "\x00") {
+		if rel != "" {
+			paths = append(paths, rel)
+		}
+	}
+	return paths, nil
+}
+
+// gitTrackedFiles returns the absolute paths of the files tracked by git under
+// dir, or nil when dir is not in a git repository.
+func gitTrackedFiles(ctx context.Context, dir string) map[string]bool {
+	paths, err := gitPaths(ctx, dir, "ls-files")
+	if err != nil {
+		return nil
+	}
+	tracked := make(map[string]bool, len(paths))
+	for _, rel := range paths {
+		tracked[filepath.Join(dir, rel)] = true
+	}
+	return tracked
+}
+
+// RepoRoot returns the top-level directory of the git repository containing
+// dir, per `git rev-parse --show-toplevel`, or dir itself when it is not in a
+// git repository. dir is kept when it already is the top level, so a
+// symlinked workspace path is not replaced by its target.
+func RepoRoot(ctx context.Context, dir string) string {
+	root, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return dir
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil && real == root {
+		return dir
+	}
+	return root
+}
@@ -0,0 +1,80 @@
+package nvim
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// initRepo creates a git repository in a temporary directory with files
+// committed, and returns its path.
+func initRepo(t *testing.T, files ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := canonicalPath(t.TempDir())
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(context.Background(), dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	git("config", "commit.gpgsign", "false")
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	return dir
+}
+
+func TestGitPathsSpecialCharacters(t *testing.T) {
+	names := []string{"my file.go", "café.go", "日本/語.go"}
+	dir := initRepo(t, names...)
+	ctx := context.Background()
+
+	tracked, err := gitPaths(ctx, dir, "ls-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if !slices.Contains(tracked, name) {
+			t.Errorf("ls-files = %q, want %q verbatim", tracked, name)
+		}
+	}
+
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("changed\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changed, err := gitPaths(ctx, dir, "diff", "--name-only", "HEAD", "--")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(changed)
+	want := slices.Sorted(slices.Values(names))
+	if !slices.Equal(changed, want) {
+		t.Errorf("diff --name-only = %q, want %q", changed, want)
+	}
+
+	files := gitTrackedFiles(ctx, dir)
+	for _, name := range names {
+		if !files[filepath.Join(dir, name)] {
+			t.Errorf("gitTrackedFiles misses %s", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package nvim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatGitHub renders the diagnostics as GitHub Actions workflow commands so
+// a CI step can annotate the pull request. Paths are relative to the
+// workspace, as GitHub expects paths relative to the repository root.
+func FormatGitHub(r *Report) string {
+	lines := make([]string, 0, len(r.Diagnostics))
+	for _, d := range r.Diagnostics {
+		props := fmt.Sprintf("file=%s,line=%d", escapeGitHubProperty(r.displayPath(r.relPath(d.File))), d.Line)
+		if !d.ColMissing {
+			props += fmt.Sprintf(",col=%d", d.Col)
+		}
+		if title := githubTitle(d); title != "" {
+			props += ",title=" + escapeGitHubProperty(title)
+		}
+		lines = append(lines, fmt.Sprintf("::%s %s::%s", githubCommand(d.Severity), props, escapeGitHubData(d.Message)))
+	}
+	if r.Gate != nil && r.Gate.Failed {
+		for _, t := range r.Gate.Tripped {
+			lines = append(lines, "::error::"+escapeGitHubData("lint gate failed: "+t))
+		}
+	} else if r.Gate != nil {
+		lines = append(lines, "::notice::"+escapeGitHubData(r.Gate.summary()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// githubCommand maps a diagnostic severity to a workflow annotation command.
+func githubCommand(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// githubTitle builds the annotation title from the source and code.
+func githubTitle(d Diagnostic) string {
+	switch {
+	case d.Source != "" && d.Code != "":
+		return d.Source + " " + d.Code
+	case d.Source != "":
+		return d.Source
+	default:
+		return d.Code
+	}
+}
+
+// escapeGitHubData escapes a workflow command message.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
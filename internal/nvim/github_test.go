@@ -0,0 +1,42 @@
+package nvim
+
+import "testing"
+
+func TestEscapeGitHub(t *testing.T) {
+	tests := []struct {
+		in, data, property string
+	}{
+		{"plain", "plain", "plain"},
+		{"100% sure", "100%25 sure", "100%25 sure"},
+		{"%0A literal", "%250A literal", "%250A literal"},
+		{"line\nbreak\r\n", "line%0Abreak%0D%0A", "line%0Abreak%0D%0A"},
+		{"a:b,c", "a:b,c", "a%3Ab%2Cc"},
+		{"dir/a b.go", "dir/a b.go", "dir/a b.go"},
+		{"::error::x", "::error::x", "%3A%3Aerror%3A%3Ax"},
+	}
+	for _, tt := range tests {
+		if got := escapeGitHubData(tt.in); got != tt.data {
+			t.Errorf("escapeGitHubData(%q) = %q, want %q", tt.in, got, tt.data)
+		}
+		if got := escapeGitHubProperty(tt.in); got != tt.property {
+			t.Errorf("escapeGitHubProperty(%q) = %q, want %q", tt.in, got, tt.property)
+		}
+	}
+}
+
+func TestFormatGitHub(t *testing.T) {
+	r := &Report{
+		Workspace: "/ws",
+		Diagnostics: []Diagnostic{
+			{File: "/ws/a,b.go", Line: 3, Col: 7, Severity: "error", Message: "50% done\nnext", Source: "vet", Code: "x:y"},
+			{File: "/ws/c.go", Line: 1, ColMissing: true, Severity: "hint", Message: "hm"},
+		},
+		Gate: &GateResult{Failed: true, Tripped: []string{"*: > 0 error (found 1)"}},
+	}
+	want := "::error file=a%2Cb.go,line=3,col=7,title=vet x%3Ay::50%25 done%0Anext\n" +
+		"::notice file=c.go,line=1::hm\n" +
+		"::error::lint gate failed: *: > 0 error (found 1)"
+	if got := FormatGitHub(r); got != want {
+		t.Errorf("FormatGitHub() =\n%s\nwant\n%s", got, want)
+	}
+}
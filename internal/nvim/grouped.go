@@ -0,0 +1,83 @@
+package nvim
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// uncodedGroup heads the diagnostics without a code in FormatGroupedByCode.
+const uncodedGroup = "uncoded"
+
+// FormatGroupedByCode renders the diagnostics grouped under a
+// "== source:code (count) ==" header per rule, most frequent rule first, so
+// the same issue can be fixed across files in one go. Diagnostics without a
+// code are grouped under "uncoded". The report sections follow as in
+// FormatText.
+func FormatGroupedByCode(r *Report) string {
+	groups := make(map[string][]Diagnostic)
+	for _, d := range r.Diagnostics {
+		key := codeKey(d)
+		groups[key] = append(groups[key], d)
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(groups[b]), len(groups[a])), cmp.Compare(a, b))
+	})
+
+	var lines []string
+	for _, key := range keys {
+		section := []string{fmt.Sprintf("== %s (%d) ==", key, len(groups[key]))}
+		for _, d := range groups[key] {
+			section = append(section, r.display(d).String())
+		}
+		lines = appendSection(lines, section...)
+	}
+	return strings.Join(appendSections(lines, r), "\n")
+}
+
+// codeKey names the rule of d as "source:code", or just the code when the
+// source is unknown.
+func codeKey(d Diagnostic) string {
+	switch {
+	case d.Code == "":
+		return uncodedGroup
+	case d.Source == "":
+		return d.Code
+	default:
+		return d.Source + ":" + d.Code
+	}
+}
+
+// severityGroups heads the groups of FormatGroupedBySeverity, indexed by
+// severityRank.
+var severityGroups = [...]string{1: "ERRORS", 2: "WARNINGS", 3: "INFO", 4: "HINTS", 5: "OTHER"}
+
+// FormatGroupedBySeverity renders the diagnostics under a
+// "== ERRORS (count) ==" header per severity, most severe first, each group
+// in position order so the most important problems are read first. Empty
+// groups are left out. The report sections follow as in FormatText.
+func FormatGroupedBySeverity(r *Report) string {
+	diags := slices.Clone(r.Diagnostics)
+	sortBySeverity(diags)
+
+	var lines []string
+	for start := 0; start < len(diags); {
+		rank := severityRank(diags[start].Severity)
+		end := start
+		for end < len(diags) && severityRank(diags[end].Severity) == rank {
+			end++
+		}
+		section := []string{fmt.Sprintf("== %s (%d) ==", severityGroups[rank], end-start)}
+		for _, d := range diags[start:end] {
+			section = append(section, r.display(d).String())
+		}
+		lines = appendSection(lines, section...)
+		start = end
+	}
+	return strings.Join(appendSections(lines, r), "\n")
+}
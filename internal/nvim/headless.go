@@ -0,0 +1,126 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+//go:embed lua/headless_servers.lua
+var headlessServersLua string
+
+// ConnectHeadless marks a session started by StartHeadless.
+const ConnectHeadless = "headless"
+
+// HeadlessAttachTimeout bounds how long a headless session may take to attach
+// an LSP client to the opened files.
+const HeadlessAttachTimeout = 10 * time.Second
+
+// Environment variables configuring the headless fallback.
+const (
+	envHeadless        = "NVIM_LSP_MCP_HEADLESS"
+	envNvimBin         = "NVIM_LSP_MCP_NVIM_BIN"
+	envHeadlessInit    = "NVIM_LSP_MCP_HEADLESS_INIT"
+	envHeadlessCmd     = "NVIM_LSP_MCP_HEADLESS_CMD"
+	envHeadlessServers = "NVIM_LSP_MCP_HEADLESS_SERVERS"
+)
+
+// HeadlessEnabled reports whether a headless Neovim may be started when no
+// running session matches the workspace.
+func HeadlessEnabled() bool {
+	return os.Getenv(envHeadless) == "1"
+}
+
+// StartHeadless starts an embedded `nvim --headless --embed` in workspace. The
+// user's config is loaded unless NVIM_LSP_MCP_HEADLESS_INIT names another init
+// file, and NVIM_LSP_MCP_HEADLESS_SERVERS starts fallback servers, so LSP
+// attaches to the files the diagnostics tools open. Close stops the process;
+// it is also killed when ctx is done.
+func StartHeadless(ctx context.Context, workspace string) (*Client, error) {
+	bin := os.Getenv(envNvimBin)
+	if bin == "" {
+		bin = "nvim"
+	}
+	servers, err := parseHeadlessServers(os.Getenv(envHeadlessServers))
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--headless", "--embed"}
+	if init := os.Getenv(envHeadlessInit); init != "" {
+		if _, err := os.Stat(init); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envHeadlessInit, err)
+		}
+		args = append(args, "-u", init)
+	}
+	for cmd := range strings.SplitSeq(os.Getenv(envHeadlessCmd), "\n") {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			args = append(args, "--cmd", cmd)
+		}
+	}
+
+	logger.Infof("nvim: starting headless %s %s in %s", bin, strings.Join(args, " "), workspace)
+	n, err := nv.NewChildProcess(
+		nv.ChildProcessCommand(bin),
+		nv.ChildProcessArgs(args...),
+		nv.ChildProcessDir(workspace),
+		nv.ChildProcessContext(ctx),
+		nv.ChildProcessLogf(logger.Infof),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("start headless %s: %w", bin, err)
+	}
+	c := &Client{NV: n, addr: "embed:" + workspace, method: ConnectHeadless, workspace: workspace}
+	if len(servers) > 0 {
+		if err := c.call(ctx, func(n *nv.Nvim) error { return n.ExecLua(headlessServersLua, nil, servers) }); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("configure headless servers: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// parseHeadlessServers parses "filetype=command args;..." into a map from
+// filetype to the server command's argv.
+func parseHeadlessServers(spec string) (map[string][]string, error) {
+	servers := make(map[string][]string)
+	for entry := range strings.SplitSeq(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		filetype, cmd, ok := strings.Cut(entry, "=")
+		argv := strings.Fields(cmd)
+		if !ok || strings.TrimSpace(filetype) == "" || len(argv) == 0 {
+			return nil, fmt.Errorf("invalid %s entry %q: want filetype=command", envHeadlessServers, entry)
+		}
+		servers[strings.TrimSpace(filetype)] = argv
+	}
+	return servers, nil
+}
+
+// waitForHeadlessClients waits until an LSP client is attached in a headless
+// session. Without a config that starts servers, it would otherwise silently
+// report no diagnostics. The wait runs inside Neovim, bounded by ctx.
+func waitForHeadlessClients(ctx context.Context, c *Client) error {
+	attached, err := callResult(ctx, c, func(n *nv.Nvim, out *bool) error {
+		return n.ExecLua("return vim.wait(..., function() return #vim.lsp.get_clients() > 0 end, 100)",
+			out, luaTimeout(ctx, HeadlessAttachTimeout))
+	})
+	if err != nil {
+		return err
+	}
+	if !attached {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("no LSP client attached in headless Neovim after %s: point %s at a config that enables LSP, or map filetypes to servers with %s",
+			HeadlessAttachTimeout, envHeadlessInit, envHeadlessServers)
+	}
+	return nil
+}
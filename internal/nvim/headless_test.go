@@ -0,0 +1,36 @@
+package nvim
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestHeadlessRefreshWithoutFiles(t *testing.T) {
+	changed := []string{"/ws/a.go", "/ws/b.py"}
+	for _, method := range []string{ConnectHeadless, ConnectListenAddress} {
+		t.Run(method, func(t *testing.T) {
+			var opened []string
+			c := newFakeClient(t, "fake", fakeSession{changed: changed, opened: &opened})
+			c.method = method
+
+			refreshed, _, err := refreshWorkspaceDiagnostics(context.Background(), c, nil, "/ws", MaxFilesToReload, false, true, nil, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if method != ConnectHeadless {
+				// A running session only refreshes files its clients handle
+				if len(refreshed) != 0 {
+					t.Errorf("refreshed = %v, want none without clients", refreshed)
+				}
+				return
+			}
+			if !slices.Equal(refreshed, changed) || !slices.Equal(opened, changed) {
+				t.Errorf("refreshed = %v, opened = %v, want the changed files %v", refreshed, opened, changed)
+			}
+			if err := waitForHeadlessClients(context.Background(), c); err != nil {
+				t.Errorf("waitForHeadlessClients() = %v, want the clients of the opened files", err)
+			}
+		})
+	}
+}
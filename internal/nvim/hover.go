@@ -0,0 +1,75 @@
+package nvim
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// Hover returns the hover documentation of all attached LSP clients for the
+// position in file, joined as markdown.
+func Hover(ctx context.Context, c *Client, file string, pos Position) (string, error) {
+	bp, err := resolvePosition(ctx, c, file, pos)
+	if err != nil {
+		return "", err
+	}
+	return hoverAt(ctx, c, bp)
+}
+
+// hoverAt returns the joined hover documentation for a resolved position.
+func hoverAt(ctx context.Context, c *Client, bp bufferPosition) (string, error) {
+	responses, err := requestAt(ctx, c, bp, "textDocument/hover", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, resp := range responses {
+		if len(resp.Error) > 0 {
+			logger.Warnf("nvim: hover error from %s: %s", resp.Client, resp.Error)
+			continue
+		}
+		var hover struct {
+			Contents json.RawMessage `json:"contents"`
+		}
+		if err := json.Unmarshal(resp.Result, &hover); err != nil {
+			continue
+		}
+		if text := markedText(hover.Contents); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n---\n\n"), nil
+}
+
+// markedText flattens hover contents, which may be a MarkupContent, a
+// MarkedString or an array of MarkedStrings, into markdown.
+func markedText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var markup struct {
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &markup); err == nil && markup.Value != "" {
+		if markup.Language != "" {
+			return "```" + markup.Language + "\n" + strings.TrimSpace(markup.Value) + "\n```"
+		}
+		return strings.TrimSpace(markup.Value)
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var parts []string
+		for _, item := range list {
+			if text := markedText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package nvim
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lineRange is an inclusive range of 1-based lines.
+type lineRange struct{ start, end int }
+
+// changedLines maps the absolute path of each changed file to the line ranges
+// of its hunks, widened by the context lines on either side. A nil slice marks a
+// file whose every line is new, such as an untracked file.
+type changedLines map[string][]lineRange
+
+// hunkHeader matches the new-file range of a unified diff hunk header, e.g.
+// "@@ -10,2 +12,3 @@".
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// gitChangedLines returns the lines changed in the working copy of dir
+// relative to HEAD, staged or not, per `git diff -U0 HEAD`, plus the
+// untracked files as wholly changed. Only files under dir are listed, with
+// --relative making the diff paths relative to dir like those of ls-files,
+// so dir may be a subdirectory of the repository.
+func gitChangedLines(ctx context.Context, dir string, contextLines int) (changedLines, error) {
+	out, err := runGit(ctx, dir, "-c", "core.quotepath=false", "diff", "-U0", "--relative", "--no-prefix", "--no-color", "--no-ext-diff", "HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+	changed := parseHunks(out, dir, contextLines)
+	untracked, err := gitPaths(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range untracked {
+		changed[filepath.Join(dir, rel)] = nil
+	}
+	return changed, nil
+}
+
+// parseHunks parses the hunks of a unified diff with zero context and no
+// path prefixes into the changed line ranges per file under dir. A pure
+// deletion marks the lines on both sides of where the lines were removed.
+func parseHunks(diff, dir string, contextLines int) changedLines {
+	changed := make(changedLines)
+	file := ""
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "+++ "); ok {
+			file = ""
+			// git appends a tab to names containing spaces
+			if name := strings.TrimSuffix(rest, "\t"); name != "/dev/null" {
+				file = filepath.Join(dir, name)
+				changed[file] = []lineRange{}
+			}
+			continue
+		}
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil || file == "" {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		end := start + count - 1
+		if count == 0 {
+			end = start + 1
+		}
+		changed[file] = append(changed[file], lineRange{start: max(1, start-contextLines), end: end + contextLines})
+	}
+	return changed
+}
+
+// touches reports whether d lies on a changed line of its file.
+func (changed changedLines) touches(d Diagnostic) bool {
+	ranges, ok := changed[d.File]
+	if !ok {
+		return false
+	}
+	if ranges == nil {
+		return true
+	}
+	end := max(d.EndLine, d.Line)
+	for _, r := range ranges {
+		if d.Line <= r.end && end >= r.start {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the changed files and hunk counts for logging.
+func (changed changedLines) String() string {
+	hunks := 0
+	for _, ranges := range changed {
+		hunks += len(ranges)
+	}
+	return fmt.Sprintf("%d files, %d hunks", len(changed), hunks)
+}
@@ -0,0 +1,83 @@
+package nvim
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseHunks(t *testing.T) {
+	diff := `diff --git a.go a.go
+--- a.go
++++ a.go
+@@ -3 +3 @@ func a() {
+-old
++new
+@@ -10,0 +11,2 @@ func b() {
++added
++added
+@@ -20,3 +21,0 @@
+-gone
+diff --git my file.go my file.go
+--- my file.go	
++++ my file.go	
+@@ -1 +1 @@
+-x
++y
+diff --git removed.go removed.go
+--- removed.go
++++ /dev/null
+@@ -1 +0,0 @@
+-x
+`
+	got := parseHunks(diff, "/ws", 1)
+	want := changedLines{
+		"/ws/a.go":       {{2, 4}, {10, 13}, {20, 23}},
+		"/ws/my file.go": {{1, 2}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseHunks() = %v, want %v", got, want)
+	}
+	for file, ranges := range want {
+		if !slices.Equal(got[file], ranges) {
+			t.Errorf("parseHunks()[%s] = %v, want %v", file, got[file], ranges)
+		}
+	}
+}
+
+func TestGitChangedLinesSubdirectory(t *testing.T) {
+	repo := initRepo(t, "top.go", "sub/x.go")
+	ws := filepath.Join(repo, "sub")
+	for _, name := range []string{"top.go", "sub/x.go"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("one\nTWO\nthree\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(ws, "new.go"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := gitChangedLines(context.Background(), ws, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := filepath.Join(ws, "x.go")
+	if !slices.Equal(changed[x], []lineRange{{2, 2}}) {
+		t.Errorf("changed lines of %s = %v, want [{2 2}] (all: %v)", x, changed[x], changed)
+	}
+	if ranges, ok := changed[filepath.Join(ws, "new.go")]; !ok || ranges != nil {
+		t.Errorf("untracked new.go = %v, %v, want wholly changed", ranges, ok)
+	}
+	if _, ok := changed[filepath.Join(repo, "top.go")]; ok {
+		t.Errorf("top.go outside the workspace is listed: %v", changed)
+	}
+	if len(changed) != 2 {
+		t.Errorf("changed = %v, want only x.go and new.go", changed)
+	}
+
+	if !changed.touches(Diagnostic{File: x, Line: 2}) || changed.touches(Diagnostic{File: x, Line: 3}) {
+		t.Errorf("touches does not follow the hunk of %s", x)
+	}
+}
@@ -0,0 +1,147 @@
+package nvim
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// IgnoreFile names the file in the workspace root listing gitignore-style
+// patterns of files whose diagnostics are dropped.
+const IgnoreFile = ".nvimlspmcpignore"
+
+// ignoreRule is one compiled pattern of an ignore file.
+type ignoreRule struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// ignoreRules are the rules of an ignore file, in file order.
+type ignoreRules []ignoreRule
+
+// ignoreCache holds the parsed ignore file of each workspace, keyed by path
+// and invalidated when the file's mtime changes.
+var ignoreCache = struct {
+	sync.Mutex
+	entries map[string]ignoreCacheEntry
+}{entries: make(map[string]ignoreCacheEntry)}
+
+type ignoreCacheEntry struct {
+	mtime time.Time
+	rules ignoreRules
+}
+
+// loadIgnoreRules returns the rules of the ignore file in workspace, or nil
+// when there is none.
+func loadIgnoreRules(workspace string) ignoreRules {
+	path := filepath.Join(workspace, IgnoreFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	ignoreCache.Lock()
+	defer ignoreCache.Unlock()
+	if entry, ok := ignoreCache.entries[path]; ok && entry.mtime.Equal(info.ModTime()) {
+		return entry.rules
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Warnf("nvim: failed to read %s: %v", path, err)
+		return nil
+	}
+	rules := parseIgnoreRules(string(data))
+	logger.Infof("nvim: loaded %d patterns from %s", len(rules), path)
+	ignoreCache.entries[path] = ignoreCacheEntry{mtime: info.ModTime(), rules: rules}
+	return rules
+}
+
+// parseIgnoreRules compiles the patterns of an ignore file. Blank lines and
+// lines starting with # are skipped, and a leading ! negates a pattern.
+func parseIgnoreRules(content string) ignoreRules {
+	var rules ignoreRules
+	for line := range strings.SplitSeq(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := false
+		if after, ok := strings.CutPrefix(line, "!"); ok {
+			negate, line = true, after
+		}
+		re, err := regexp.Compile(ignorePatternRegexp(line))
+		if err != nil {
+			logger.Warnf("nvim: ignoring invalid %s pattern %q: %v", IgnoreFile, line, err)
+			continue
+		}
+		rules = append(rules, ignoreRule{re: re, negate: negate})
+	}
+	return rules
+}
+
+// ignorePatternRegexp translates a gitignore-style pattern into a regular
+// expression matching workspace-relative, slash-separated paths. A pattern
+// containing a slash other than a trailing one is anchored at the workspace
+// root, otherwise it matches at any depth. A match on a directory covers
+// everything below it.
+func ignorePatternRegexp(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "/")
+	var b strings.Builder
+	if strings.Contains(pattern, "/") {
+		b.WriteString("^")
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		b.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '[':
+			if end := strings.IndexByte(pattern[i:], ']'); end > 1 {
+				class := pattern[i+1 : i+end]
+				if after, ok := strings.CutPrefix(class, "!"); ok {
+					class = "^" + after
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+				continue
+			}
+			b.WriteString(regexp.QuoteMeta("["))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("(/.*)?$")
+	return b.String()
+}
+
+// ignored reports whether file, an absolute path, matches the rules. As in
+// gitignore, the last matching pattern decides.
+func (rules ignoreRules) ignored(file, workspace string) bool {
+	rel, err := filepath.Rel(workspace, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, rule := range rules {
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,41 @@
+package nvim
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// envSeverityLabels customizes how severities render in text output, e.g.
+// "error=E,warning=W,info=I,hint=H".
+const envSeverityLabels = "NVIM_LSP_MCP_SEVERITY_LABELS"
+
+// severityLabels is parsed from envSeverityLabels on first use.
+var severityLabels = sync.OnceValue(func() map[string]string {
+	labels := make(map[string]string)
+	spec := os.Getenv(envSeverityLabels)
+	for entry := range strings.SplitSeq(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		sev, label, ok := strings.Cut(entry, "=")
+		sev = strings.ToLower(strings.TrimSpace(sev))
+		if !ok || !isSeverity(sev) {
+			logger.Warnf("nvim: ignoring invalid %s entry %q", envSeverityLabels, entry)
+			continue
+		}
+		labels[sev] = strings.TrimSpace(label)
+	}
+	return labels
+})
+
+// severityLabel returns the text output label of a canonical severity,
+// uppercased unless overridden by NVIM_LSP_MCP_SEVERITY_LABELS.
+func severityLabel(severity string) string {
+	if label, ok := severityLabels()[severity]; ok {
+		return label
+	}
+	return strings.ToUpper(severity)
+}
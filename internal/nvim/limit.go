@@ -0,0 +1,58 @@
+package nvim
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+const (
+	// DefaultMaxConcurrentRequests bounds the LSP requests in flight against a
+	// single Neovim session. Neovim handles RPC serially, so a large fan-out
+	// only queues inside the editor and makes it unresponsive.
+	DefaultMaxConcurrentRequests = 2
+
+	envMaxConcurrentRequests = "NVIM_LSP_MCP_MAX_CONCURRENT_REQUESTS"
+)
+
+var (
+	requestSlotsMu sync.Mutex
+	requestSlots   = map[string]chan struct{}{}
+)
+
+// maxConcurrentRequests returns the configured per-session request limit.
+func maxConcurrentRequests() int {
+	v := os.Getenv(envMaxConcurrentRequests)
+	if v == "" {
+		return DefaultMaxConcurrentRequests
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		logger.Warnf("nvim: invalid %s=%q, using %d", envMaxConcurrentRequests, v, DefaultMaxConcurrentRequests)
+		return DefaultMaxConcurrentRequests
+	}
+	return n
+}
+
+// acquireRequestSlot blocks until an LSP request slot for the client's session
+// is free or ctx is done. Slots are shared by every client attached to the same
+// socket; the returned func releases the slot.
+func (c *Client) acquireRequestSlot(ctx context.Context) (func(), error) {
+	requestSlotsMu.Lock()
+	slots, ok := requestSlots[c.addr]
+	if !ok {
+		slots = make(chan struct{}, maxConcurrentRequests())
+		requestSlots[c.addr] = slots
+	}
+	requestSlotsMu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,63 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+//go:embed lua/lsp_clients.lua
+var lspClientsLua string
+
+// probedMethods are the LSP methods reported in ClientInfo.Methods.
+var probedMethods = []string{
+	"textDocument/hover",
+	"textDocument/definition",
+	"textDocument/declaration",
+	"textDocument/typeDefinition",
+	"textDocument/implementation",
+	"textDocument/references",
+	"textDocument/documentSymbol",
+	"textDocument/documentHighlight",
+	"textDocument/signatureHelp",
+	"textDocument/completion",
+	"textDocument/codeAction",
+	"textDocument/codeLens",
+	"textDocument/rename",
+	"textDocument/formatting",
+	"textDocument/rangeFormatting",
+	"textDocument/inlayHint",
+	"textDocument/diagnostic",
+	"textDocument/didSave",
+	"workspace/symbol",
+}
+
+// ClientInfo describes an active LSP client of a Neovim session.
+type ClientInfo struct {
+	ID              int      `json:"id"`
+	Name            string   `json:"name"`
+	RootDir         string   `json:"rootDir,omitempty"`
+	AttachedBuffers int      `json:"attachedBuffers"`
+	Methods         []string `json:"methods"`
+}
+
+// ListClients returns the active LSP clients with the methods they support.
+func ListClients(ctx context.Context, c *Client) ([]ClientInfo, error) {
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(lspClientsLua, out, probedMethods) })
+	if err != nil {
+		return nil, err
+	}
+	var clients []ClientInfo
+	if err := json.Unmarshal([]byte(jsonStr), &clients); err != nil {
+		return nil, fmt.Errorf("invalid client info: %w", err)
+	}
+	for i := range clients {
+		if clients[i].Methods == nil {
+			clients[i].Methods = []string{}
+		}
+	}
+	return clients, nil
+}
@@ -0,0 +1,100 @@
+package nvim
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+)
+
+// lspDiagnostic is an LSP Diagnostic as sent by a server.
+type lspDiagnostic struct {
+	Range              LspRange        `json:"range"`
+	Severity           int             `json:"severity,omitempty"`
+	Code               json.RawMessage `json:"code,omitempty"`
+	CodeDescription    json.RawMessage `json:"codeDescription,omitempty"`
+	Source             string          `json:"source,omitempty"`
+	Message            string          `json:"message"`
+	Tags               []int           `json:"tags,omitempty"`
+	RelatedInformation json.RawMessage `json:"relatedInformation,omitempty"`
+	Data               json.RawMessage `json:"data,omitempty"`
+}
+
+// lspFileDiagnostics are the LSP diagnostics of one document, shaped like
+// the params of textDocument/publishDiagnostics.
+type lspFileDiagnostics struct {
+	URI         string          `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+// lspOriginal returns the user_data.lsp table of a diagnostic item, the
+// diagnostic as the server sent it, or nil if it has none.
+func lspOriginal(item map[string]any) json.RawMessage {
+	userData, _ := item["user_data"].(map[string]any)
+	lsp, ok := userData["lsp"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	raw, err := json.Marshal(lsp)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// lsp returns d as an LSP Diagnostic, from the original the server sent when
+// it was kept, otherwise rebuilt from the display fields for diagnostics
+// that did not come from an LSP client. The severity is always d's, so
+// severity overrides apply.
+func (d Diagnostic) lsp() lspDiagnostic {
+	var ld lspDiagnostic
+	if len(d.Original) == 0 || json.Unmarshal(d.Original, &ld) != nil {
+		ld = lspDiagnostic{
+			Range: LspRange{
+				Start: LspPosition{Line: d.Line - 1, Character: max(d.Col-1, 0)},
+				End:   LspPosition{Line: max(d.EndLine, d.Line) - 1, Character: max(d.EndCol-1, d.Col-1, 0)},
+			},
+			Source:  d.Source,
+			Message: d.Message,
+		}
+		if d.Code != "" {
+			ld.Code, _ = json.Marshal(d.Code)
+		}
+	}
+	ld.Severity = d.SeverityLevel
+	return ld
+}
+
+// fileURI returns the file:// URI of path, taken relative to root when it is
+// not absolute.
+func fileURI(path, root string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// FormatLSP renders the diagnostics as a JSON array of
+// {"uri", "diagnostics"} objects, one per file in order of appearance, whose
+// diagnostics conform to the LSP Diagnostic interface: 0-based ranges in the
+// server's position encoding, numeric severities and the code, tags and
+// relatedInformation as the server sent them. The report sections are left
+// out so the output can be fed to LSP tooling as is.
+func FormatLSP(r *Report) (string, error) {
+	files := []lspFileDiagnostics{}
+	index := make(map[string]int)
+	for _, d := range r.Diagnostics {
+		uri := fileURI(d.File, r.Workspace)
+		i, ok := index[uri]
+		if !ok {
+			i = len(files)
+			index[uri] = i
+			files = append(files, lspFileDiagnostics{URI: uri})
+		}
+		files[i].Diagnostics = append(files[i].Diagnostics, d.lsp())
+	}
+	data, err := json.Marshal(files)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,38 @@
+-- Send an LSP request to all clients attached to a buffer and wait for the responses
+-- Args: bufnr (int), method (string), params (table or nil), row (int, 0-based, or -1), col (int, 0-based byte column), timeoutMs (int)
+-- Returns: JSON [{client: string, result: any, error: any}]
+--
+-- textDocument is always filled in. When row is given, position is filled in
+-- too, converting the byte column to each client's offset encoding.
+
+local bufnr, method, params, row, col, timeoutMs = ...
+
+local function makeParams(client)
+	local p = vim.deepcopy(params or {})
+	p.textDocument = { uri = vim.uri_from_bufnr(bufnr) }
+	if row >= 0 then
+		local text = vim.api.nvim_buf_get_lines(bufnr, row, row + 1, false)[1] or ""
+		p.position = {
+			line = row,
+			character = vim.str_utfindex(text, client.offset_encoding, math.min(col, #text), false),
+		}
+	end
+	return p
+end
+
+local responses = vim.lsp.buf_request_sync(bufnr, method, makeParams, timeoutMs) or {}
+
+local out = {}
+for clientId, resp in pairs(responses) do
+	local client = vim.lsp.get_client_by_id(clientId)
+	table.insert(out, {
+		client = client and client.name or tostring(clientId),
+		result = resp.result,
+		error = resp.err,
+	})
+end
+
+if #out == 0 then
+	return "[]"
+end
+return vim.json.encode(out)
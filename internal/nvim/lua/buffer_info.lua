@@ -0,0 +1,29 @@
+-- Collect buffer metadata in a single call
+-- Args: bufs (table of buffer numbers)
+-- Returns: JSON [{bufnr: int, name: string, filetype: string, clients: [string], lines: int, diagnostics: int}] for valid buffers
+
+local bufs = ...
+
+local infos = {}
+for _, bufnr in ipairs(bufs) do
+	if vim.api.nvim_buf_is_valid(bufnr) then
+		local clients = {}
+		for _, cl in ipairs(vim.lsp.get_clients({ bufnr = bufnr })) do
+			table.insert(clients, cl.name)
+		end
+		table.insert(infos, {
+			bufnr = bufnr,
+			name = vim.api.nvim_buf_get_name(bufnr),
+			filetype = vim.bo[bufnr].filetype,
+			-- Empty tables would encode as JSON objects
+			clients = #clients > 0 and clients or nil,
+			lines = vim.api.nvim_buf_line_count(bufnr),
+			diagnostics = #vim.diagnostic.get(bufnr),
+		})
+	end
+end
+
+if #infos == 0 then
+	return "[]"
+end
+return vim.json.encode(infos)
@@ -0,0 +1,30 @@
+-- Filter buffers by where they come from
+-- Args: bufs (table of buffer numbers), source (string: listed, loaded or args)
+-- Returns: table of the buffer numbers matching source
+
+local bufs, source = ...
+
+local args = {}
+if source == "args" then
+	for _, arg in ipairs(vim.fn.argv()) do
+		args[vim.fn.fnamemodify(arg, ":p")] = true
+	end
+end
+
+local out = {}
+for _, bufnr in ipairs(bufs) do
+	if vim.api.nvim_buf_is_valid(bufnr) then
+		local keep
+		if source == "listed" then
+			keep = vim.bo[bufnr].buflisted
+		elseif source == "loaded" then
+			keep = vim.api.nvim_buf_is_loaded(bufnr)
+		else
+			keep = args[vim.api.nvim_buf_get_name(bufnr)] == true
+		end
+		if keep then
+			table.insert(out, bufnr)
+		end
+	end
+end
+return out
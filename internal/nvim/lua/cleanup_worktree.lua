@@ -0,0 +1,19 @@
+-- Wipe buffers opened from a temporary worktree and stop LSP clients rooted in it
+-- Args: root (string), files (table of absolute file paths)
+-- Returns: nil (side-effect only)
+
+local root, files = ...
+
+for _, filepath in ipairs(files) do
+	-- bufexists/bufadd match the exact name, unlike the pattern of vim.fn.bufnr
+	if vim.fn.bufexists(filepath) == 1 then
+		pcall(vim.api.nvim_buf_delete, vim.fn.bufadd(filepath), { force = true })
+	end
+end
+
+-- Clients started for the worktree root are of no further use
+for _, cl in ipairs(vim.lsp.get_clients()) do
+	if cl.root_dir and vim.startswith(cl.root_dir, root) then
+		cl:stop()
+	end
+end
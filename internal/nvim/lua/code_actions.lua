@@ -0,0 +1,183 @@
+-- List or apply the LSP code actions available at a position
+-- Args: bufnr (int), row (int, 0-based), col (int, 0-based byte column), kind (string, "" for any),
+--       apply (int, 1-based index of the action to apply, or 0 to only list), timeoutMs (int),
+--       title (string, title of the action to apply instead of an index: an exact match, else the
+--       only action whose title contains it ignoring case; "" for none)
+-- Returns: JSON {actions: [{title, kind, client, isPreferred}], applied: string, changes: [{file, before, after}], unsaved: [string]}
+
+local bufnr, row, col, kind, apply, timeoutMs, title = ...
+
+-- Diagnostics on the line give servers the context for quick fixes
+local lspDiagnostics = {}
+for _, d in ipairs(vim.diagnostic.get(bufnr, { lnum = row })) do
+	if d.user_data and d.user_data.lsp then
+		table.insert(lspDiagnostics, d.user_data.lsp)
+	end
+end
+
+local function makeParams(client)
+	local text = vim.api.nvim_buf_get_lines(bufnr, row, row + 1, false)[1] or ""
+	local pos = {
+		line = row,
+		character = vim.str_utfindex(text, client.offset_encoding, math.min(col, #text), false),
+	}
+	return {
+		textDocument = { uri = vim.uri_from_bufnr(bufnr) },
+		range = { start = pos, ["end"] = pos },
+		context = { diagnostics = lspDiagnostics, only = kind ~= "" and { kind } or nil },
+	}
+end
+
+local responses = vim.lsp.buf_request_sync(bufnr, "textDocument/codeAction", makeParams, timeoutMs) or {}
+
+-- Flatten in client id order so indexes are stable between listing and applying
+local clientIds = vim.tbl_keys(responses)
+table.sort(clientIds)
+local actions = {}
+for _, id in ipairs(clientIds) do
+	local client = vim.lsp.get_client_by_id(id)
+	for _, action in ipairs(responses[id].result or {}) do
+		table.insert(actions, { action = action, client = client })
+	end
+end
+
+local listed = {}
+for _, a in ipairs(actions) do
+	table.insert(listed, {
+		title = a.action.title,
+		kind = a.action.kind,
+		client = a.client and a.client.name or "",
+		isPreferred = a.action.isPreferred,
+	})
+end
+local result = { actions = #listed > 0 and listed or nil }
+
+-- Select by title: an exact match wins, otherwise the single fuzzy match
+local function selectByTitle()
+	local matches = {}
+	for i, a in ipairs(actions) do
+		if a.action.title == title then
+			return i
+		end
+		if a.action.title:lower():find(title:lower(), 1, true) then
+			table.insert(matches, i)
+		end
+	end
+	if #matches == 1 then
+		return matches[1]
+	end
+	local titles = {}
+	for _, a in ipairs(actions) do
+		table.insert(titles, string.format("%q", a.action.title))
+	end
+	local available = #titles > 0 and table.concat(titles, ", ") or "none"
+	if #matches > 1 then
+		error(string.format("%d code actions match title %q, use the exact title; available: %s", #matches, title, available), 0)
+	end
+	error(string.format("no code action matches title %q; available: %s", title, available), 0)
+end
+
+if title ~= "" then
+	apply = selectByTitle()
+end
+if apply == 0 then
+	return vim.json.encode(result)
+end
+local chosen = actions[apply]
+if not chosen or not chosen.client then
+	error(string.format("no code action at index %d (%d available)", apply, #actions), 0)
+end
+local action, client = chosen.action, chosen.client
+
+-- Lazy servers leave out the edit until the action is resolved
+if not action.edit and type(action.command) ~= "string" and client:supports_method("codeAction/resolve") then
+	local resp = client:request_sync("codeAction/resolve", action, timeoutMs, bufnr)
+	if resp and resp.result then
+		action = resp.result
+	elseif resp and resp.err then
+		error(string.format("codeAction/resolve failed: %s", resp.err.message or vim.inspect(resp.err)), 0)
+	end
+end
+
+-- Snapshot the buffers the action may touch: those named by its edit, plus the
+-- buffers attached to the client since commands may apply edits server-side
+local snapshot = {}
+local function track(b)
+	if snapshot[b] == nil and vim.api.nvim_buf_is_loaded(b) then
+		snapshot[b] = {
+			tick = vim.api.nvim_buf_get_changedtick(b),
+			modified = vim.bo[b].modified,
+			lines = vim.api.nvim_buf_get_lines(b, 0, -1, false),
+		}
+	end
+end
+
+local editUris = {}
+if action.edit then
+	for uri in pairs(action.edit.changes or {}) do
+		table.insert(editUris, uri)
+	end
+	for _, change in ipairs(action.edit.documentChanges or {}) do
+		if change.textDocument then
+			table.insert(editUris, change.textDocument.uri)
+		end
+	end
+end
+for _, uri in ipairs(editUris) do
+	local b = vim.uri_to_bufnr(uri)
+	vim.fn.bufload(b)
+	track(b)
+	-- Never mix an edit into a user's unsaved changes
+	if snapshot[b] and snapshot[b].modified then
+		error(string.format("%s has unsaved changes; save or discard them first", vim.api.nvim_buf_get_name(b)), 0)
+	end
+end
+for _, b in ipairs(vim.lsp.get_buffers_by_client_id(client.id)) do
+	track(b)
+end
+
+if action.edit then
+	vim.lsp.util.apply_workspace_edit(action.edit, client.offset_encoding)
+end
+local command = type(action.command) == "table" and action.command or nil
+if type(action.command) == "string" then
+	-- The action is a bare Command
+	command = action
+end
+if command then
+	client:exec_cmd(command, { bufnr = bufnr })
+	-- Servers usually apply command edits via workspace/applyEdit; let it arrive
+	vim.wait(math.min(1000, timeoutMs), function()
+		for b, snap in pairs(snapshot) do
+			if vim.api.nvim_buf_get_changedtick(b) ~= snap.tick then
+				return true
+			end
+		end
+		return false
+	end, 50)
+end
+
+-- Write changed buffers, except those that already had unsaved user changes
+local changes, unsaved = {}, {}
+for b, snap in pairs(snapshot) do
+	if vim.api.nvim_buf_get_changedtick(b) ~= snap.tick then
+		local name = vim.api.nvim_buf_get_name(b)
+		if snap.modified then
+			table.insert(unsaved, name)
+		else
+			vim.api.nvim_buf_call(b, function()
+				vim.cmd("silent update")
+			end)
+		end
+		table.insert(changes, {
+			file = name,
+			before = table.concat(snap.lines, "\n") .. "\n",
+			after = table.concat(vim.api.nvim_buf_get_lines(b, 0, -1, false), "\n") .. "\n",
+		})
+	end
+end
+
+result.applied = action.title
+result.changes = #changes > 0 and changes or nil
+result.unsaved = #unsaved > 0 and unsaved or nil
+return vim.json.encode(result)
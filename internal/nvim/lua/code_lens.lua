@@ -0,0 +1,76 @@
+-- List the LSP code lenses of a buffer, optionally executing one's command
+-- Args: bufnr (int), execute (int, 1-based index of the lens to execute, or 0 to only list), timeoutMs (int)
+-- Returns: JSON {lenses: [{line, col, endLine, endCol, title, command, client}], executed: string,
+--   supported: bool (whether any attached client supports textDocument/codeLens)}
+
+local bufnr, execute, timeoutMs = ...
+
+local supported = false
+for _, client in ipairs(vim.lsp.get_clients({ bufnr = bufnr })) do
+	if client:supports_method("textDocument/codeLens") then
+		supported = true
+	end
+end
+if not supported then
+	return vim.json.encode({ supported = false })
+end
+
+local responses = vim.lsp.buf_request_sync(bufnr, "textDocument/codeLens", {
+	textDocument = { uri = vim.uri_from_bufnr(bufnr) },
+}, timeoutMs) or {}
+
+-- Flatten in client id order so indexes are stable between listing and executing
+local clientIds = vim.tbl_keys(responses)
+table.sort(clientIds)
+local lenses = {}
+for _, id in ipairs(clientIds) do
+	local client = vim.lsp.get_client_by_id(id)
+	for _, lens in ipairs(responses[id].result or {}) do
+		-- Lazy servers leave out the command until the lens is resolved
+		if not lens.command and client and client:supports_method("codeLens/resolve") then
+			local resp = client:request_sync("codeLens/resolve", lens, timeoutMs, bufnr)
+			if resp and resp.result then
+				lens = resp.result
+			end
+		end
+		table.insert(lenses, { lens = lens, client = client })
+	end
+end
+
+-- Convert an LSP position to a 1-based line and byte column
+local function toBufferPos(pos, client)
+	local text = vim.api.nvim_buf_get_lines(bufnr, pos.line, pos.line + 1, false)[1] or ""
+	local encoding = client and client.offset_encoding or "utf-16"
+	local ok, byte = pcall(vim.str_byteindex, text, encoding, pos.character, false)
+	return pos.line + 1, (ok and byte or pos.character) + 1
+end
+
+local listed = {}
+for _, l in ipairs(lenses) do
+	local line, col = toBufferPos(l.lens.range.start, l.client)
+	local endLine, endCol = toBufferPos(l.lens.range["end"], l.client)
+	table.insert(listed, {
+		line = line,
+		col = col,
+		endLine = endLine,
+		endCol = endCol,
+		title = l.lens.command and l.lens.command.title or "",
+		command = l.lens.command and l.lens.command.command or "",
+		client = l.client and l.client.name or "",
+	})
+end
+local result = { supported = true, lenses = #listed > 0 and listed or nil }
+
+if execute == 0 then
+	return vim.json.encode(result)
+end
+local chosen = lenses[execute]
+if not chosen or not chosen.client then
+	error(string.format("no code lens at index %d (%d available)", execute, #lenses), 0)
+end
+if not chosen.lens.command or chosen.lens.command.command == "" then
+	error(string.format("code lens %d has no command to execute", execute), 0)
+end
+chosen.client:exec_cmd(chosen.lens.command, { bufnr = bufnr })
+result.executed = chosen.lens.command.title
+return vim.json.encode(result)
@@ -0,0 +1,29 @@
+-- Read the current window's cursor, or its visual selection while in visual mode
+-- Returns: JSON {bufnr, startLine, startCol, endLine, endCol, selection} with 1-based lines and byte columns
+
+local bufnr = vim.api.nvim_get_current_buf()
+local cursor = vim.api.nvim_win_get_cursor(0)
+local startLine, startCol = cursor[1], cursor[2] + 1
+local endLine, endCol = startLine, startCol
+
+local mode = vim.fn.mode()
+local selection = mode == "v" or mode == "V" or mode == "\22"
+if selection then
+	local v = vim.fn.getpos("v")
+	startLine, startCol = v[2], v[3]
+	if startLine > endLine or (startLine == endLine and startCol > endCol) then
+		startLine, startCol, endLine, endCol = endLine, endCol, startLine, startCol
+	end
+	if mode == "V" then
+		startCol, endCol = 1, vim.v.maxcol
+	end
+end
+
+return vim.json.encode({
+	bufnr = bufnr,
+	startLine = startLine,
+	startCol = startCol,
+	endLine = endLine,
+	endCol = endCol,
+	selection = selection,
+})
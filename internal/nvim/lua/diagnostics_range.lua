@@ -0,0 +1,17 @@
+-- Fetch the diagnostics of a buffer within a block of lines
+-- Args: bufnr (int), first (0-based line), last (exclusive, -1 for no limit)
+-- Returns: JSON array of the vim.diagnostic.get items in the block
+
+local bufnr, first, last = ...
+
+local items = {}
+for _, d in ipairs(vim.diagnostic.get(bufnr)) do
+	if d.lnum >= first and (last < 0 or d.lnum < last) then
+		table.insert(items, d)
+	end
+end
+
+if #items == 0 then
+	return "[]"
+end
+return vim.json.encode(items)
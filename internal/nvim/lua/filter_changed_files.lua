@@ -1,16 +1,20 @@
 -- Filter changed files by LSP supported filetypes
--- Args: workspace (string), maxFiles (int)
+-- Args: workspace (string), maxFiles (int), anyFiletype (bool, keep every file
+--   with a detected filetype, for sessions whose servers only start once a
+--   file is opened)
 -- Returns: JSON {filtered: [paths], origCount: int, filteredCount: int}
 
-local workspace, maxFiles = ...
+local workspace, maxFiles, anyFiletype = ...
 
--- Get changed files via git diff
-local origCwd = vim.fn.getcwd()
-vim.fn.chdir(workspace)
-local gitOut = vim.fn.system("git diff --name-only HEAD")
-vim.fn.chdir(origCwd)
+-- Get changed files via git diff, NUL-separated and unquoted so paths with
+-- spaces or non-ASCII characters come through verbatim
+local gitResult = vim.system(
+	{ "git", "-c", "core.quotepath=false", "diff", "--name-only", "-z", "HEAD" },
+	{ cwd = workspace, text = false }
+):wait()
+local gitOut = gitResult.code == 0 and gitResult.stdout or ""
 
-local relFiles = vim.fn.split(vim.trim(gitOut), "\n")
+local relFiles = vim.split(gitOut, "\0", { plain = true })
 local origCount = 0
 for _, rel in ipairs(relFiles) do
 	if rel ~= "" then
@@ -88,7 +92,8 @@ local filtered = {}
 local matched = 0
 local unsupported = 0
 for _, f in ipairs(absFiles) do
-	if not supportedFTs[f.ft] then
+	local supported = supportedFTs[f.ft] or (anyFiletype and f.ft ~= "")
+	if not supported then
 		unsupported = unsupported + 1
 	elseif #filtered < maxFiles then
 		table.insert(filtered, f.path)
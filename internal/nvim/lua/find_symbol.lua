@@ -0,0 +1,66 @@
+-- Find the declarations of a symbol by name in a buffer
+-- Args: bufnr (int), name (string), timeoutMs (int)
+-- Returns: JSON {candidates: [{name, kind, row, col}], source: "symbols" or "text"}
+--   with 0-based rows and byte columns
+--
+-- Document symbols are searched first, matching the name exactly or as the
+-- last dotted component (e.g. "(*Server).Start" for "Start"). Without a match
+-- the first whole-word occurrence in the buffer text is used.
+
+local bufnr, name, timeoutMs = ...
+
+-- Convert an LSP position to a 0-based row and byte column
+local function toBufferPos(pos, encoding)
+	local text = vim.api.nvim_buf_get_lines(bufnr, pos.line, pos.line + 1, false)[1] or ""
+	local ok, byte = pcall(vim.str_byteindex, text, encoding, pos.character, false)
+	return pos.line, ok and byte or pos.character
+end
+
+local function matches(symbolName)
+	return symbolName == name or vim.endswith(symbolName, "." .. name)
+end
+
+local candidates = {}
+local responses = vim.lsp.buf_request_sync(bufnr, "textDocument/documentSymbol", {
+	textDocument = { uri = vim.uri_from_bufnr(bufnr) },
+}, timeoutMs) or {}
+for id, resp in pairs(responses) do
+	local client = vim.lsp.get_client_by_id(id)
+	local encoding = client and client.offset_encoding or "utf-16"
+	local function visit(symbols)
+		for _, s in ipairs(symbols or {}) do
+			-- DocumentSymbol has a selectionRange, SymbolInformation a location
+			local range = s.selectionRange or (s.location and s.location.range)
+			if range and matches(s.name) then
+				local row, col = toBufferPos(range.start, encoding)
+				table.insert(candidates, {
+					name = s.name,
+					kind = vim.lsp.protocol.SymbolKind[s.kind] or tostring(s.kind),
+					row = row,
+					col = col,
+				})
+			end
+			visit(s.children)
+		end
+	end
+	visit(resp.result)
+end
+if #candidates > 0 then
+	table.sort(candidates, function(a, b)
+		return a.row < b.row or (a.row == b.row and a.col < b.col)
+	end)
+	return vim.json.encode({ candidates = candidates, source = "symbols" })
+end
+
+-- Fall back to the first whole-word occurrence in the text
+local pattern = "%f[%w_]" .. vim.pesc(name) .. "%f[^%w_]"
+for row, line in ipairs(vim.api.nvim_buf_get_lines(bufnr, 0, -1, false)) do
+	local col = line:find(pattern)
+	if col then
+		return vim.json.encode({
+			candidates = { { name = name, kind = "text", row = row - 1, col = col - 1 } },
+			source = "text",
+		})
+	end
+end
+return vim.json.encode({ source = "text" })
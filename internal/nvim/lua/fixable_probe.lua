@@ -0,0 +1,50 @@
+-- Probe whether any code action exists at each of several positions
+-- Args: probes (table of {file: string, row: int, col: int}, 0-based row and byte column), timeoutMs (int)
+-- Returns: JSON array of booleans, one per probe
+
+local probes, timeoutMs = ...
+
+local fixable, pending = {}, 0
+for i, probe in ipairs(probes) do
+	fixable[i] = false
+	-- bufexists/bufadd match the exact name, unlike the pattern of vim.fn.bufnr
+	local bufnr = vim.fn.bufexists(probe.file) == 1 and vim.fn.bufadd(probe.file) or -1
+	if bufnr > 0 and vim.api.nvim_buf_is_loaded(bufnr) and #vim.lsp.get_clients({ bufnr = bufnr }) > 0 then
+		-- Diagnostics on the line give servers the context for quick fixes
+		local lspDiagnostics = {}
+		for _, d in ipairs(vim.diagnostic.get(bufnr, { lnum = probe.row })) do
+			if d.user_data and d.user_data.lsp then
+				table.insert(lspDiagnostics, d.user_data.lsp)
+			end
+		end
+		local text = vim.api.nvim_buf_get_lines(bufnr, probe.row, probe.row + 1, false)[1] or ""
+		pending = pending + 1
+		vim.lsp.buf_request_all(bufnr, "textDocument/codeAction", function(client)
+			local pos = {
+				line = probe.row,
+				character = vim.str_utfindex(text, client.offset_encoding, math.min(probe.col, #text), false),
+			}
+			return {
+				textDocument = { uri = vim.uri_from_bufnr(bufnr) },
+				range = { start = pos, ["end"] = pos },
+				context = { diagnostics = lspDiagnostics },
+			}
+		end, function(results)
+			for _, res in pairs(results) do
+				if res.result and #res.result > 0 then
+					fixable[i] = true
+				end
+			end
+			pending = pending - 1
+		end)
+	end
+end
+
+vim.wait(timeoutMs, function()
+	return pending == 0
+end, 20)
+
+if #fixable == 0 then
+	return "[]"
+end
+return vim.json.encode(fixable)
@@ -0,0 +1,15 @@
+-- Start fallback LSP servers by filetype in a headless session
+-- Args: servers (table mapping filetype to server command argv)
+
+local servers = ...
+
+local group = vim.api.nvim_create_augroup("nvim_lsp_mcp_headless", { clear = true })
+for filetype, cmd in pairs(servers) do
+	vim.api.nvim_create_autocmd("FileType", {
+		group = group,
+		pattern = filetype,
+		callback = function(ev)
+			vim.lsp.start({ name = cmd[1], cmd = cmd, root_dir = vim.fn.getcwd() }, { bufnr = ev.buf })
+		end,
+	})
+end
@@ -0,0 +1,22 @@
+-- Report whether any LSP client still has work in progress
+-- Args: none
+-- Returns: JSON {clients: int, clientNames: [names of all clients], busy: bool,
+--   busyClients: [names of the busy clients]}
+
+local clients = vim.lsp.get_clients()
+local clientNames = {}
+local busyClients = {}
+for _, cl in ipairs(clients) do
+	table.insert(clientNames, cl.name)
+	-- pending holds the $/progress tokens that have begun but not ended
+	if cl.progress and cl.progress.pending and next(cl.progress.pending) ~= nil then
+		table.insert(busyClients, cl.name)
+	end
+end
+
+return vim.json.encode({
+	clients = #clients,
+	clientNames = #clientNames > 0 and clientNames or nil,
+	busy = #busyClients > 0,
+	busyClients = #busyClients > 0 and busyClients or nil,
+})
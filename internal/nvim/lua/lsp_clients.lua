@@ -0,0 +1,29 @@
+-- Describe all active LSP clients and the methods their servers support
+-- Args: methods (table of LSP method names to probe)
+-- Returns: JSON [{id: int, name: string, rootDir: string, attachedBuffers: int, methods: [string]}]
+
+local methods = ...
+
+local infos = {}
+for _, cl in ipairs(vim.lsp.get_clients()) do
+	-- supports_method consults the server_capabilities negotiated at initialize
+	local supported = {}
+	for _, method in ipairs(methods) do
+		if cl:supports_method(method) then
+			table.insert(supported, method)
+		end
+	end
+	table.insert(infos, {
+		id = cl.id,
+		name = cl.name,
+		rootDir = cl.root_dir,
+		attachedBuffers = vim.tbl_count(cl.attached_buffers or {}),
+		-- Empty tables would encode as JSON objects
+		methods = #supported > 0 and supported or nil,
+	})
+end
+
+if #infos == 0 then
+	return "[]"
+end
+return vim.json.encode(infos)
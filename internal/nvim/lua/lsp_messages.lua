@@ -0,0 +1,88 @@
+-- Capture window/logMessage and window/showMessage notifications of LSP servers and return the recent ones
+-- Args: limit (int, most recent messages to return), max (int, messages kept),
+--   logLines (int, lines of the LSP log file to return, 0 for none), stop (bool, remove the capture instead)
+-- Returns: JSON {messages: [{time, client, method, type, message}], log: [string], logPath: string}
+--
+-- The capture wraps the global handlers once per session and keeps the last
+-- max messages in a ring, so only messages sent after the first call are seen.
+-- The LSP log covers earlier ones at the log level Neovim was configured with.
+-- Stopping restores the original handlers, unless something wrapped them
+-- since, in which case the capture's wrappers only pass messages through.
+
+local limit, max, logLines, stop = ...
+
+local state = _G._nvim_lsp_mcp_messages
+if stop then
+	if state then
+		state.active = false
+		for method, wrapper in pairs(state.wrappers or {}) do
+			if vim.lsp.handlers[method] == wrapper then
+				vim.lsp.handlers[method] = state.originals[method]
+			end
+		end
+		_G._nvim_lsp_mcp_messages = nil
+	end
+	return "{}"
+end
+
+if not state then
+	state = { items = {}, active = true, originals = {}, wrappers = {} }
+	_G._nvim_lsp_mcp_messages = state
+	local types = { "error", "warning", "info", "log", "debug" }
+	for _, method in ipairs({ "window/logMessage", "window/showMessage" }) do
+		local original = vim.lsp.handlers[method]
+		state.originals[method] = original
+		state.wrappers[method] = function(err, result, ctx, config)
+			if state.active and result and result.message then
+				local client = vim.lsp.get_client_by_id(ctx.client_id)
+				table.insert(state.items, {
+					time = os.time(),
+					client = client and client.name or tostring(ctx.client_id),
+					method = method,
+					type = types[result.type] or tostring(result.type),
+					message = result.message,
+				})
+				while #state.items > state.max do
+					table.remove(state.items, 1)
+				end
+			end
+			if original then
+				return original(err, result, ctx, config)
+			end
+		end
+		vim.lsp.handlers[method] = state.wrappers[method]
+	end
+end
+state.max = max
+
+local messages = {}
+for i = math.max(1, #state.items - limit + 1), #state.items do
+	table.insert(messages, state.items[i])
+end
+
+-- Tail of the LSP log file, read from its end so a big log stays cheap
+local log = {}
+local logPath = vim.lsp.get_log_path()
+if logLines > 0 then
+	local f = io.open(logPath, "rb")
+	if f then
+		local size = f:seek("end")
+		local start = math.max(0, size - 256 * logLines)
+		f:seek("set", start)
+		local lines = vim.split(f:read("*a") or "", "\n", { trimempty = true })
+		f:close()
+		if start > 0 then
+			-- The first line is likely cut
+			table.remove(lines, 1)
+		end
+		for i = math.max(1, #lines - logLines + 1), #lines do
+			table.insert(log, lines[i])
+		end
+	end
+end
+
+return vim.json.encode({
+	messages = #messages > 0 and messages or nil,
+	log = #log > 0 and log or nil,
+	logPath = logPath,
+})
@@ -0,0 +1,29 @@
+-- Map diagnostic namespaces to the name of the client that produces them
+-- Args: none
+-- Returns: JSON {[namespace id]: name}
+--
+-- LSP namespaces are resolved through vim.lsp.diagnostic.get_namespace for both
+-- push and pull diagnostics of every client. Any other namespace (e.g. one
+-- created by nvim-lint) maps to its own namespace name.
+
+local names = {}
+
+for _, cl in ipairs(vim.lsp.get_clients()) do
+	for _, isPull in ipairs({ false, true }) do
+		local ok, ns = pcall(vim.lsp.diagnostic.get_namespace, cl.id, isPull)
+		if ok and ns then
+			names[tostring(ns)] = cl.name
+		end
+	end
+end
+
+for ns, meta in pairs(vim.diagnostic.get_namespaces()) do
+	if names[tostring(ns)] == nil then
+		names[tostring(ns)] = meta.name
+	end
+end
+
+if next(names) == nil then
+	return "{}"
+end
+return vim.json.encode(names)
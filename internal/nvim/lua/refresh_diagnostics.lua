@@ -1,13 +1,35 @@
 -- Refresh diagnostics for given files by loading/refreshing buffers and notifying LSP clients
--- Args: files (table of absolute file paths)
--- Returns: nil (side-effect only)
+-- Args: files (table of absolute file paths), force (bool, reload buffers with unsaved changes),
+--   reload (bool, load/reload buffers from disk; when false only loaded buffers are notified),
+--   clients (optional table of client names to notify, compared case-insensitively; empty means all),
+--   guard (bool, leave the current buffer alone while the user is in insert, replace, visual or select mode)
+-- Returns: { skipped = paths whose buffer has unsaved changes, unloaded = paths without a
+--   loaded buffer, left alone because reload is false, editing = paths left alone because
+--   the user is editing them, mode = the editing mode }
 
-local files = ...
+local files, force, reload, clients, guard = ...
+
+-- The buffer the user is actively editing, if any
+local editingBuf, mode = nil, vim.api.nvim_get_mode().mode
+if guard and mode:match("^[iRvVsS\22\19]") then
+	editingBuf = vim.api.nvim_get_current_buf()
+end
+
+-- Lowercased names of the clients to notify, nil for all
+local wanted = nil
+if clients and #clients > 0 then
+	wanted = {}
+	for _, name in ipairs(clients) do
+		wanted[name:lower()] = true
+	end
+end
 
 -- Local function to refresh a single buffer and notify LSP
 local function refreshAndNotify(filepath, bufnr)
 	-- Load or refresh the buffer from disk
-	if not vim.api.nvim_buf_is_loaded(bufnr) then
+	if not reload then
+		-- Buffers are kept in sync with disk by the user (e.g. autoread)
+	elseif not vim.api.nvim_buf_is_loaded(bufnr) then
 		-- Use nvim_buf_call to safely load the buffer
 		vim.api.nvim_buf_call(bufnr, function()
 			vim.cmd("silent! edit")
@@ -23,17 +45,40 @@ local function refreshAndNotify(filepath, bufnr)
 	vim.schedule(function()
 		-- Send LSP notifications after buffer is reloaded
 		for _, client in ipairs(vim.lsp.get_clients({ bufnr = bufnr })) do
-			if client:supports_method("textDocument/didSave") then
+			if (not wanted or wanted[client.name:lower()]) and client:supports_method("textDocument/didSave") then
 				client:notify("textDocument/didSave", {
-					textDocument = { uri = vim.uri_from_fname(filepath) },
+					textDocument = { uri = vim.uri_from_bufnr(bufnr) },
 				})
 			end
 		end
 	end)
 end
 
--- Process each file
+-- Look up the buffer of a path by its exact name. vim.fn.bufnr would treat the
+-- path as a pattern, mismatching names with characters such as [ or #.
+local function findBuffer(filepath)
+	if reload then
+		return vim.fn.bufadd(filepath)
+	end
+	if vim.fn.bufexists(filepath) == 0 then
+		return -1
+	end
+	return vim.fn.bufadd(filepath)
+end
+
+-- Process each file, leaving buffers with unsaved changes alone unless forced
+local skipped, unloaded, editing = {}, {}, {}
 for _, filepath in ipairs(files) do
-	local bufnr = vim.fn.bufnr(filepath, true)
-	refreshAndNotify(filepath, bufnr)
+	local bufnr = findBuffer(filepath)
+	if bufnr == editingBuf then
+		table.insert(editing, filepath)
+	elseif not reload and (bufnr == -1 or not vim.api.nvim_buf_is_loaded(bufnr)) then
+		table.insert(unloaded, filepath)
+	elseif not force and vim.api.nvim_buf_is_loaded(bufnr) and vim.bo[bufnr].modified then
+		table.insert(skipped, filepath)
+	else
+		refreshAndNotify(filepath, bufnr)
+	end
 end
+
+return { skipped = skipped, unloaded = unloaded, editing = editing, mode = mode }
@@ -0,0 +1,123 @@
+-- Compute the WorkspaceEdit of a rename without applying it
+-- Args: bufnr (int), row (int, 0-based), col (int, 0-based byte column), newName (string),
+--   timeoutMs (int), withChanges (bool)
+-- Returns: JSON {supported: bool, client: string, files: [{file, edits}], operations: [string],
+--   changes: [{file, before, after}] (only withChanges)}
+--
+-- Nothing is written and no buffer is modified: with withChanges the edits are
+-- applied to scratch copies of the files to return their new content.
+
+local bufnr, row, col, newName, timeoutMs, withChanges = ...
+
+local client
+for _, c in ipairs(vim.lsp.get_clients({ bufnr = bufnr })) do
+	if c:supports_method("textDocument/rename") then
+		client = c
+		break
+	end
+end
+if not client then
+	return vim.json.encode({ supported = false })
+end
+
+local text = vim.api.nvim_buf_get_lines(bufnr, row, row + 1, false)[1] or ""
+local position = {
+	line = row,
+	character = vim.str_utfindex(text, client.offset_encoding, math.min(col, #text), false),
+}
+local textDocument = { uri = vim.uri_from_bufnr(bufnr) }
+
+if client:supports_method("textDocument/prepareRename") then
+	local resp = client:request_sync("textDocument/prepareRename", {
+		textDocument = textDocument,
+		position = position,
+	}, timeoutMs, bufnr)
+	if resp and resp.err then
+		error(string.format("%s cannot rename here: %s", client.name, resp.err.message or vim.inspect(resp.err)), 0)
+	end
+	-- Responses are decoded with luanil, so a null result arrives as nil
+	if resp and not resp.err and (resp.result == nil or resp.result == vim.NIL) then
+		error(string.format("%s cannot rename at this position", client.name), 0)
+	end
+end
+
+local resp = client:request_sync("textDocument/rename", {
+	textDocument = textDocument,
+	position = position,
+	newName = newName,
+}, timeoutMs, bufnr)
+if not resp then
+	error(string.format("%s did not answer the rename request within %dms", client.name, timeoutMs), 0)
+end
+if resp.err then
+	error(string.format("%s failed to rename: %s", client.name, resp.err.message or vim.inspect(resp.err)), 0)
+end
+local edit = resp.result
+if not edit or edit == vim.NIL then
+	return vim.json.encode({ supported = true, client = client.name })
+end
+
+-- Collect the text edits per file, and describe file operations
+local editsByUri, uris, operations = {}, {}, {}
+local function addEdits(uri, edits)
+	if not editsByUri[uri] then
+		editsByUri[uri] = {}
+		table.insert(uris, uri)
+	end
+	vim.list_extend(editsByUri[uri], edits or {})
+end
+for uri, edits in pairs(edit.changes or {}) do
+	addEdits(uri, edits)
+end
+for _, change in ipairs(edit.documentChanges or {}) do
+	if change.textDocument then
+		addEdits(change.textDocument.uri, change.edits)
+	elseif change.kind == "rename" then
+		table.insert(operations, string.format("rename %s -> %s", vim.uri_to_fname(change.oldUri), vim.uri_to_fname(change.newUri)))
+	elseif change.kind == "create" or change.kind == "delete" then
+		table.insert(operations, string.format("%s %s", change.kind, vim.uri_to_fname(change.uri)))
+	end
+end
+table.sort(uris)
+
+-- Read the current content of a file, from its buffer when loaded
+local function readLines(fname)
+	if vim.fn.bufexists(fname) == 1 then
+		local b = vim.fn.bufadd(fname)
+		if vim.api.nvim_buf_is_loaded(b) then
+			return vim.api.nvim_buf_get_lines(b, 0, -1, false)
+		end
+	end
+	local ok, lines = pcall(vim.fn.readfile, fname)
+	return ok and lines or {}
+end
+
+local files, changes = {}, {}
+for _, uri in ipairs(uris) do
+	local fname = vim.uri_to_fname(uri)
+	table.insert(files, { file = fname, edits = #editsByUri[uri] })
+	if withChanges then
+		local before = readLines(fname)
+		local scratch = vim.api.nvim_create_buf(false, true)
+		vim.api.nvim_buf_set_lines(scratch, 0, -1, false, before)
+		local ok, err = pcall(vim.lsp.util.apply_text_edits, editsByUri[uri], scratch, client.offset_encoding)
+		local after = vim.api.nvim_buf_get_lines(scratch, 0, -1, false)
+		vim.api.nvim_buf_delete(scratch, { force = true })
+		if not ok then
+			error(string.format("failed to apply the rename edits to a copy of %s: %s", fname, err), 0)
+		end
+		table.insert(changes, {
+			file = fname,
+			before = table.concat(before, "\n") .. "\n",
+			after = table.concat(after, "\n") .. "\n",
+		})
+	end
+end
+
+return vim.json.encode({
+	supported = true,
+	client = client.name,
+	files = #files > 0 and files or nil,
+	operations = #operations > 0 and operations or nil,
+	changes = #changes > 0 and changes or nil,
+})
@@ -0,0 +1,39 @@
+-- Resolve a position in a file to a loaded buffer and a 0-based row and byte column
+-- Args: file (string), line (int, 1-based), col (int, 1-based), offset (int, 0-based byte offset, or -1 to use line/col)
+-- Returns: JSON {bufnr: int, row: int, col: int}
+
+local file, line, col, offset = ...
+
+if vim.fn.filereadable(file) == 0 then
+	error(string.format("file %s is not readable", file), 0)
+end
+local bufnr = vim.fn.bufadd(file)
+vim.fn.bufload(bufnr)
+local lineCount = vim.api.nvim_buf_line_count(bufnr)
+
+if offset >= 0 then
+	local size = vim.api.nvim_buf_get_offset(bufnr, lineCount)
+	if offset > size then
+		error(string.format("offset %d is out of bounds (file is %d bytes)", offset, size), 0)
+	end
+	-- Binary search for the last line starting at or before offset
+	local lo, hi = 0, lineCount - 1
+	while lo < hi do
+		local mid = math.floor((lo + hi + 1) / 2)
+		if vim.api.nvim_buf_get_offset(bufnr, mid) <= offset then
+			lo = mid
+		else
+			hi = mid - 1
+		end
+	end
+	return vim.json.encode({ bufnr = bufnr, row = lo, col = offset - vim.api.nvim_buf_get_offset(bufnr, lo) })
+end
+
+if line < 1 or line > lineCount then
+	error(string.format("line %d is out of bounds (file has %d lines)", line, lineCount), 0)
+end
+local text = vim.api.nvim_buf_get_lines(bufnr, line - 1, line, false)[1] or ""
+if col < 1 or col > #text + 1 then
+	error(string.format("col %d is out of bounds (line %d has %d bytes)", col, line, #text), 0)
+end
+return vim.json.encode({ bufnr = bufnr, row = line - 1, col = col - 1 })
@@ -0,0 +1,10 @@
+-- Wipe the buffers a workspace scan loaded, detaching their LSP clients
+-- Args: bufs (table of buffer numbers)
+
+local bufs = ...
+
+for _, bufnr in ipairs(bufs) do
+	if vim.api.nvim_buf_is_valid(bufnr) and not vim.bo[bufnr].modified then
+		pcall(vim.api.nvim_buf_delete, bufnr, {})
+	end
+end
@@ -0,0 +1,27 @@
+-- Load files into buffers for a workspace scan so LSP clients attach and check them
+-- Args: files (table of absolute file paths)
+-- Returns: JSON [{file: string, bufnr: int, opened: bool}], opened being false for buffers that
+--   were already loaded and must be left alone afterwards
+
+local files = ...
+
+local out = {}
+for _, file in ipairs(files) do
+	local bufnr = vim.fn.bufadd(file)
+	local opened = not vim.api.nvim_buf_is_loaded(bufnr)
+	if opened then
+		-- Keep scanned buffers out of the user's buffer list
+		vim.bo[bufnr].buflisted = false
+		pcall(vim.fn.bufload, bufnr)
+		-- bufload does not run filetype detection, which LSP attaches on
+		vim.api.nvim_buf_call(bufnr, function()
+			vim.cmd("silent! filetype detect")
+		end)
+	end
+	table.insert(out, { file = file, bufnr = bufnr, opened = opened })
+end
+
+if #out == 0 then
+	return "[]"
+end
+return vim.json.encode(out)
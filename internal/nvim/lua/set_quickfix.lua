@@ -0,0 +1,13 @@
+-- Populate the quickfix list with diagnostics
+-- Args: items (table of quickfix entries), title (string), replace (bool, overwrite a list not created by us)
+-- Returns: number of entries set
+
+local items, title, replace = ...
+
+local current = vim.fn.getqflist({ title = 0, size = 0 })
+if current.size > 0 and current.title ~= title and not replace then
+	error("the quickfix list holds other entries; pass replaceQuickfix to overwrite it", 0)
+end
+
+vim.fn.setqflist({}, "r", { title = title, items = items })
+return #items
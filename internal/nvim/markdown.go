@@ -0,0 +1,38 @@
+package nvim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatMarkdown renders the diagnostics as a markdown table for clients that
+// display markdown, followed by the same sections as FormatText. Paths are
+// relative to the workspace to keep the table narrow.
+func FormatMarkdown(r *Report) string {
+	var lines []string
+	if len(r.Diagnostics) > 0 {
+		lines = append(lines,
+			"| File | Line:Col | Severity | Source | Message |",
+			"| --- | --- | --- | --- | --- |",
+		)
+	}
+	for _, d := range r.Diagnostics {
+		source := d.Source
+		if d.Code != "" {
+			source = strings.TrimSpace(source + " " + d.Code)
+		}
+		pos := fmt.Sprintf("%d:%d", d.Line, d.Col)
+		if d.ColMissing {
+			pos = fmt.Sprint(d.Line)
+		}
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s | %s |",
+			escapeMarkdownCell(r.displayPath(r.relPath(d.File))), pos, d.Severity,
+			escapeMarkdownCell(source), escapeMarkdownCell(d.Message)))
+	}
+	return strings.Join(appendSections(lines, r), "\n")
+}
+
+// escapeMarkdownCell keeps s within a single table cell.
+func escapeMarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
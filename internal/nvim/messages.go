@@ -0,0 +1,66 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// MaxMessages bounds the LSP server messages kept per session and returned by
+// LspMessages.
+const MaxMessages = 200
+
+//go:embed lua/lsp_messages.lua
+var lspMessagesLua string
+
+// LspMessage is a window/logMessage or window/showMessage notification of an
+// LSP server.
+type LspMessage struct {
+	Time    int64  `json:"time"`
+	Client  string `json:"client"`
+	Method  string `json:"method"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// LspMessagesResult holds the captured server messages, oldest first, and the
+// tail of Neovim's LSP log file.
+type LspMessagesResult struct {
+	Messages []LspMessage `json:"messages"`
+	Log      []string     `json:"log"`
+	LogPath  string       `json:"logPath"`
+}
+
+// LspMessages returns the last limit (at most MaxMessages) server messages
+// captured in the session and the last logLines lines of the LSP log. The
+// first call installs the capture, wrapping the session's window/logMessage
+// and window/showMessage handlers, so it returns no messages yet; later calls
+// reuse it until StopMessages removes it.
+func LspMessages(ctx context.Context, c *Client, limit, logLines int) (*LspMessagesResult, error) {
+	if limit <= 0 || limit > MaxMessages {
+		limit = MaxMessages
+	}
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(lspMessagesLua, out, limit, MaxMessages, logLines, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result LspMessagesResult
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("invalid LSP messages: %w", err)
+	}
+	return &result, nil
+}
+
+// StopMessages removes the capture installed by LspMessages, restoring the
+// session's original handlers and dropping the captured messages. It does
+// nothing if no capture is installed.
+func StopMessages(ctx context.Context, c *Client) error {
+	return c.call(ctx, func(n *nv.Nvim) error {
+		return n.ExecLua(lspMessagesLua, nil, 0, MaxMessages, 0, true)
+	})
+}
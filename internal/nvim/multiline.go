@@ -0,0 +1,67 @@
+package nvim
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Ways of rendering messages with embedded newlines in text output, which
+// would otherwise break the one line per diagnostic layout.
+const (
+	// MultilineCollapse joins the lines of a message with a separator
+	MultilineCollapse = "collapse"
+	// MultilineIndent keeps the lines, indenting continuation lines under
+	// the first
+	MultilineIndent = "indent"
+
+	// DefaultMessageSeparator joins the lines of a collapsed message
+	DefaultMessageSeparator = " / "
+
+	// continuationIndent prefixes the continuation lines of an indented
+	// message
+	continuationIndent = "    "
+)
+
+// MultilineModes lists the values of CollectOptions.Multiline.
+var MultilineModes = []string{MultilineCollapse, MultilineIndent}
+
+// validateMultiline checks a CollectOptions.Multiline value.
+func validateMultiline(mode string) error {
+	if mode != "" && !slices.Contains(MultilineModes, mode) {
+		return fmt.Errorf("invalid multiline mode %q: want %s", mode, strings.Join(MultilineModes, ", "))
+	}
+	return nil
+}
+
+// displayMessage renders msg for text output per the report's Multiline mode,
+// collapsing by default. Blank lines and trailing whitespace are dropped.
+// Messages in columns are always collapsed, since a continuation line would
+// break the tab-separated layout.
+func (r *Report) displayMessage(msg string) string {
+	if !strings.ContainsAny(msg, "\r\n") {
+		return msg
+	}
+	var lines []string
+	for line := range strings.Lines(msg) {
+		if line = strings.TrimRight(line, " \t\r\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if r.Multiline == MultilineIndent && len(r.Columns) == 0 {
+		return strings.Join(lines, "\n"+continuationIndent)
+	}
+	sep := r.MessageSeparator
+	if sep == "" {
+		sep = DefaultMessageSeparator
+	}
+	return strings.Join(lines, sep)
+}
+
+// display returns d as rendered by the text formats, with its path and
+// message adjusted by displayPath and displayMessage.
+func (r *Report) display(d Diagnostic) Diagnostic {
+	d.File = r.displayPath(d.File)
+	d.Message = r.displayMessage(d.Message)
+	return d
+}
@@ -0,0 +1,65 @@
+package nvim
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDisplayMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		multiline string
+		separator string
+		columns   []string
+		msg       string
+		want      string
+	}{
+		{"single line", "", "", nil, "unused variable", "unused variable"},
+		{"collapse default", "", "", nil, "mismatched types\nexpected i32\nfound &str", "mismatched types / expected i32 / found &str"},
+		{"collapse explicit", MultilineCollapse, "", nil, "a\nb", "a / b"},
+		{"collapse separator", "", "; ", nil, "a\nb", "a; b"},
+		{"collapse crlf", "", "", nil, "a\r\nb\r\n", "a / b"},
+		{"collapse trailing newline", "", "", nil, "a\n", "a"},
+		{"collapse blank lines", "", "", nil, "a\n\n  \nb", "a / b"},
+		{"collapse trailing spaces", "", "", nil, "a  \n\tb\t", "a / \tb"},
+		{"indent", MultilineIndent, "", nil, "Type 'string' is not assignable\n  to type 'number'", "Type 'string' is not assignable\n      to type 'number'"},
+		{"indent crlf", MultilineIndent, "", nil, "a\r\nb\r\n", "a\n    b"},
+		{"indent trailing newline", MultilineIndent, "", nil, "a\n", "a"},
+		{"indent with columns collapses", MultilineIndent, "", []string{"file", "message"}, "a\nb", "a / b"},
+		{"lone carriage return", "", "", nil, "a\rb", "a\rb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{Multiline: tt.multiline, MessageSeparator: tt.separator, Columns: tt.columns}
+			if got := r.displayMessage(tt.msg); got != tt.want {
+				t.Errorf("displayMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTextMultiline(t *testing.T) {
+	r := &Report{
+		Multiline: MultilineIndent,
+		Diagnostics: []Diagnostic{{
+			File: "/ws/main.rs", Line: 3, Col: 5, Severity: "error",
+			Message: "mismatched types\nexpected `i32`, found `&str`\n", Source: "rustc",
+		}},
+	}
+	want := "/ws/main.rs:3:5: ERROR: mismatched types\n    expected `i32`, found `&str` (rustc)"
+	if got := FormatText(r); got != want {
+		t.Errorf("FormatText() = %q, want %q", got, want)
+	}
+	// JSON keeps the full message
+	out, err := FormatJSON(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Diagnostics[0].Message; got != r.Diagnostics[0].Message {
+		t.Errorf("FormatJSON() message = %q, want %q", got, r.Diagnostics[0].Message)
+	}
+}
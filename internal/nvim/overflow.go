@@ -0,0 +1,35 @@
+package nvim
+
+import (
+	"path/filepath"
+)
+
+// DefaultOverflowFile is the file in the workspace root that the full set of
+// diagnostics is written to when they exceed CollectOptions.OverflowThreshold
+// and no OverflowPath is given.
+const DefaultOverflowFile = ".nvim-lsp-mcp-diagnostics.json"
+
+// overflowPath returns where the overflowing diagnostics are written: path,
+// taken relative to workspace unless absolute, or DefaultOverflowFile.
+func overflowPath(path, workspace string) string {
+	if path == "" {
+		return filepath.Join(workspace, DefaultOverflowFile)
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(workspace, path)
+	}
+	return filepath.Clean(path)
+}
+
+// writeOverflow writes all diags, before any trimming, as the JSON of report
+// to the overflow file. It returns the file's path.
+func writeOverflow(report *Report, diags []Diagnostic, opts CollectOptions, workspace string) (string, error) {
+	full := *report
+	full.Diagnostics = diags
+	full.Omitted = nil
+	path := overflowPath(opts.OverflowPath, workspace)
+	if err := ExportJSON(&full, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
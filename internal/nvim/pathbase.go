@@ -0,0 +1,77 @@
+package nvim
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// resolvePathBase returns base as an absolute directory within workspace, or
+// "" if base is empty. A relative base is taken relative to the workspace.
+func resolvePathBase(base, workspace string) (string, error) {
+	if base == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(workspace, base)
+	}
+	base = filepath.Clean(base)
+	rel, err := filepath.Rel(workspace, base)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("pathBase %s is outside workspace %s", base, workspace)
+	}
+	return base, nil
+}
+
+// relativize rewrites the paths of the report relative to base, falling back
+// to workspace-relative and then absolute paths for files outside it. Run it
+// last, since later steps such as the quickfix export need absolute paths.
+func (r *Report) relativize(base, workspace string) {
+	rel := func(file string) string {
+		for _, root := range []string{base, workspace} {
+			if !withinDir(file, root) {
+				continue
+			}
+			if p, err := filepath.Rel(root, file); err == nil {
+				return p
+			}
+		}
+		return file
+	}
+	for i := range r.Diagnostics {
+		r.Diagnostics[i].File = rel(r.Diagnostics[i].File)
+	}
+	for i := range r.Omitted {
+		r.Omitted[i].File = rel(r.Omitted[i].File)
+	}
+	if len(r.Edits) > 0 {
+		edits := slices.Clone(r.Edits)
+		for i := range edits {
+			edits[i].File = rel(edits[i].File)
+		}
+		r.Edits = edits
+	}
+	for i := range r.SkippedUnsaved {
+		r.SkippedUnsaved[i] = rel(r.SkippedUnsaved[i])
+	}
+	r.Workspace = base
+}
+
+// displayPath removes the report's StripPrefix from file, a path as rendered
+// by a format, if file starts with it. A prefix without a trailing slash only
+// matches whole path elements, so "src" strips "src/a.go" but not "srcs/a.go".
+func (r *Report) displayPath(file string) string {
+	prefix := r.StripPrefix
+	if prefix == "" || !strings.HasPrefix(file, prefix) {
+		return file
+	}
+	rest := file[len(prefix):]
+	if strings.HasSuffix(prefix, "/") {
+		return rest
+	}
+	if after, ok := strings.CutPrefix(rest, "/"); ok {
+		return after
+	}
+	return file
+}
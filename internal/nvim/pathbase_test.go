@@ -0,0 +1,28 @@
+package nvim
+
+import "testing"
+
+func TestRelativize(t *testing.T) {
+	r := &Report{
+		Diagnostics: []Diagnostic{
+			{File: "/ws/pkg/a.go"},
+			{File: "/ws/cmd/main.go"},
+			{File: "/other/b.go"},
+		},
+		SkippedUnsaved: []string{"/ws/pkg/sub/c.go"},
+	}
+	r.relativize("/ws/pkg", "/ws")
+
+	want := []string{"a.go", "cmd/main.go", "/other/b.go"}
+	for i, w := range want {
+		if got := r.Diagnostics[i].File; got != w {
+			t.Errorf("diagnostic %d file = %q, want %q", i, got, w)
+		}
+	}
+	if got := r.SkippedUnsaved[0]; got != "sub/c.go" {
+		t.Errorf("skipped file = %q, want sub/c.go", got)
+	}
+	if r.Workspace != "/ws/pkg" {
+		t.Errorf("workspace = %q, want /ws/pkg", r.Workspace)
+	}
+}
@@ -0,0 +1,68 @@
+package nvim
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// canonicalPath cleans path and resolves its symlinks, so that a symlinked
+// file and its target compare equal whichever name a buffer or request uses.
+// A path that cannot be resolved, e.g. a deleted file, is only cleaned.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// withinDir reports whether path is dir or lies inside it.
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// WithinWorkspace reports whether the absolute path file lies inside
+// workspace, either as given or once both have their symlinks resolved.
+func WithinWorkspace(file, workspace string) bool {
+	return withinDir(filepath.Clean(file), workspace) || withinDir(canonicalPath(file), canonicalPath(workspace))
+}
+
+// bufferPath returns the file a buffer name refers to. A file:// buffer name
+// is converted to a path, decoding percent-escapes such as %20 for a space.
+func bufferPath(name string) string {
+	rest, ok := strings.CutPrefix(name, "file://")
+	if !ok {
+		return name
+	}
+	if decoded, err := url.PathUnescape(rest); err == nil {
+		return decoded
+	}
+	return rest
+}
+
+// filePaths converts the file:// URIs among files, as tracked by editors, to
+// filesystem paths and leaves plain paths alone. URIs with another scheme or
+// a remote host are rejected.
+func filePaths(files []string) ([]string, error) {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		if !strings.Contains(f, "://") {
+			paths = append(paths, f)
+			continue
+		}
+		u, err := url.Parse(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file URI %s: %w", f, err)
+		}
+		if u.Scheme != "file" {
+			return nil, fmt.Errorf("unsupported URI scheme %q in %s: only file:// URIs are accepted", u.Scheme, f)
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("file URI %s names remote host %s", f, u.Host)
+		}
+		paths = append(paths, u.Path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,176 @@
+package nvim
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalPathSymlink(t *testing.T) {
+	ws := canonicalPath(t.TempDir())
+	target := filepath.Join(ws, "real.go")
+	if err := os.WriteFile(target, []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(ws, "link.go")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if got := canonicalPath(link); got != target {
+		t.Errorf("canonicalPath(%s) = %s, want %s", link, got, target)
+	}
+	if got := canonicalPath(target); got != target {
+		t.Errorf("canonicalPath(%s) = %s, want it unchanged", target, got)
+	}
+	if !withinDir(canonicalPath(link), ws) {
+		t.Errorf("symlinked file %s not within workspace %s", link, ws)
+	}
+
+	// A link pointing out of the workspace resolves outside it
+	outside := filepath.Join(canonicalPath(t.TempDir()), "other.go")
+	if err := os.WriteFile(outside, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	escape := filepath.Join(ws, "escape.go")
+	if err := os.Symlink(outside, escape); err != nil {
+		t.Fatal(err)
+	}
+	if withinDir(canonicalPath(escape), ws) {
+		t.Errorf("link %s to %s reported within workspace %s", escape, outside, ws)
+	}
+
+	// A missing file is only cleaned
+	missing := ws + "/sub/../gone.go"
+	if got, want := canonicalPath(missing), filepath.Join(ws, "gone.go"); got != want {
+		t.Errorf("canonicalPath(%s) = %s, want %s", missing, got, want)
+	}
+}
+
+func TestWithinDir(t *testing.T) {
+	tests := []struct {
+		path, dir string
+		want      bool
+	}{
+		{"/ws", "/ws", true},
+		{"/ws/a.go", "/ws", true},
+		{"/ws/sub/a.go", "/ws", true},
+		{"/ws-other/a.go", "/ws", false},
+		{"/ws-other", "/ws", false},
+		{"/wsx/a.go", "/ws", false},
+		{"/a.go", "/ws", false},
+		{"/ws/../other/a.go", "/ws", false},
+		{"/ws/..foo/a.go", "/ws", true},
+	}
+	for _, tt := range tests {
+		if got := withinDir(tt.path, tt.dir); got != tt.want {
+			t.Errorf("withinDir(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestWithinWorkspace(t *testing.T) {
+	root := canonicalPath(t.TempDir())
+	ws := filepath.Join(root, "ws")
+	if err := os.Mkdir(ws, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "a.go"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(ws, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	tests := []struct {
+		file, workspace string
+		want            bool
+	}{
+		{filepath.Join(ws, "a.go"), ws, true},
+		{filepath.Join(link, "a.go"), ws, true},
+		{filepath.Join(ws, "a.go"), link, true},
+		{filepath.Join(root, "ws-other", "a.go"), ws, false},
+		{filepath.Join(ws, "..", "a.go"), ws, false},
+	}
+	for _, tt := range tests {
+		if got := WithinWorkspace(tt.file, tt.workspace); got != tt.want {
+			t.Errorf("WithinWorkspace(%q, %q) = %v, want %v", tt.file, tt.workspace, got, tt.want)
+		}
+	}
+}
+
+func TestFilePaths(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain path", "/a b/c.go", "/a b/c.go"},
+		{"file URI", "file:///src/main.go", "/src/main.go"},
+		{"encoded space", "file:///a%20b/c.go", "/a b/c.go"},
+		{"localhost", "file://localhost/a%20b/c.go", "/a b/c.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filePaths([]string{tt.in})
+			if err != nil {
+				t.Fatalf("filePaths(%q): %v", tt.in, err)
+			}
+			if got[0] != tt.want {
+				t.Errorf("filePaths(%q) = %q, want %q", tt.in, got[0], tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"https://example.com/a.go", "file://remote/a.go"} {
+		if _, err := filePaths([]string{bad}); err == nil {
+			t.Errorf("filePaths(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestSpecialCharacterPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		buffer string
+		uri    string
+		want   string
+	}{
+		{"space", "/ws/my file.go", "file:///ws/my%20file.go", "/ws/my file.go"},
+		{"hash", "/ws/issue#12.go", "file:///ws/issue%2312.go", "/ws/issue#12.go"},
+		{"unicode", "/ws/café/日本.go", "file:///ws/caf%C3%A9/%E6%97%A5%E6%9C%AC.go", "/ws/café/日本.go"},
+		{"unencoded unicode", "/ws/café.go", "file:///ws/café.go", "/ws/café.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bufferPath(tt.buffer); got != tt.want {
+				t.Errorf("bufferPath(%q) = %q, want %q", tt.buffer, got, tt.want)
+			}
+			if got := bufferPath(tt.uri); got != tt.want {
+				t.Errorf("bufferPath(%q) = %q, want %q", tt.uri, got, tt.want)
+			}
+			paths, err := filePaths([]string{tt.buffer, tt.uri})
+			if err != nil {
+				t.Fatalf("filePaths: %v", err)
+			}
+			for _, got := range paths {
+				if got != tt.want {
+					t.Errorf("filePaths() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// Real files with such names resolve to themselves
+	ws := canonicalPath(t.TempDir())
+	for _, name := range []string{"my file.go", "issue#12.go", "日本.go"} {
+		file := filepath.Join(ws, name)
+		if err := os.WriteFile(file, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := canonicalPath(file); got != file || !withinDir(got, ws) {
+			t.Errorf("canonicalPath(%q) = %q, want it unchanged within %s", file, got, ws)
+		}
+	}
+}
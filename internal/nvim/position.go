@@ -0,0 +1,87 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// RequestTimeout bounds how long LSP requests wait for server responses.
+const RequestTimeout = 5 * time.Second
+
+//go:embed lua/resolve_position.lua
+var resolvePositionLua string
+
+//go:embed lua/buf_request.lua
+var bufRequestLua string
+
+// Position addresses a location in a file, either by 1-based Line and Col or,
+// when Offset is set, by a 0-based byte offset into the file.
+type Position struct {
+	Line   int
+	Col    int
+	Offset *int
+}
+
+// bufferPosition is a Position resolved against a loaded buffer, with a 0-based
+// row and byte column.
+type bufferPosition struct {
+	Bufnr int `json:"bufnr"`
+	Row   int `json:"row"`
+	Col   int `json:"col"`
+}
+
+// ClientResponse is the response of one LSP client to a request.
+type ClientResponse struct {
+	Client string          `json:"client"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// resolvePosition loads file into a buffer and converts pos to a buffer
+// position, validating that it is within bounds.
+func resolvePosition(ctx context.Context, c *Client, file string, pos Position) (bufferPosition, error) {
+	offset := -1
+	if pos.Offset != nil {
+		if *pos.Offset < 0 {
+			return bufferPosition{}, fmt.Errorf("offset %d must not be negative", *pos.Offset)
+		}
+		offset = *pos.Offset
+	}
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(resolvePositionLua, out, file, pos.Line, pos.Col, offset)
+	})
+	if err != nil {
+		return bufferPosition{}, err
+	}
+	var bp bufferPosition
+	if err := json.Unmarshal([]byte(jsonStr), &bp); err != nil {
+		return bufferPosition{}, fmt.Errorf("invalid position: %w", err)
+	}
+	return bp, nil
+}
+
+// requestAt sends an LSP request for the position to every client attached to
+// its buffer. textDocument and position are added to params per client.
+func requestAt(ctx context.Context, c *Client, bp bufferPosition, method string, params map[string]any) ([]ClientResponse, error) {
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(bufRequestLua, out, bp.Bufnr, method, params, bp.Row, bp.Col, luaTimeout(ctx, RequestTimeout))
+	})
+	if err != nil {
+		return nil, err
+	}
+	var responses []ClientResponse
+	if err := json.Unmarshal([]byte(jsonStr), &responses); err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", method, err)
+	}
+	return responses, nil
+}
@@ -0,0 +1,45 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// envPreCollectLua opts in to running the caller-supplied Lua of
+// CollectOptions.PreCollectLua, which executes with the full rights of the
+// user's Neovim session.
+const envPreCollectLua = "NVIM_LSP_MCP_PRE_COLLECT_LUA"
+
+// PreCollectLuaEnabled reports whether PreCollectLua may be run.
+func PreCollectLuaEnabled() bool {
+	return os.Getenv(envPreCollectLua) == "1"
+}
+
+// runPreCollectLua executes the Lua of CollectOptions.PreCollectLua in the
+// session: the contents of the file when it is an absolute path to a .lua
+// file, otherwise the code itself.
+func runPreCollectLua(ctx context.Context, c *Client, lua string) error {
+	if !PreCollectLuaEnabled() {
+		return fmt.Errorf("preCollectLua is disabled: set %s=1 to allow running Lua in the Neovim session", envPreCollectLua)
+	}
+	code := lua
+	if filepath.IsAbs(lua) && strings.HasSuffix(lua, ".lua") {
+		data, err := os.ReadFile(lua)
+		if err != nil {
+			return fmt.Errorf("failed to read preCollectLua file: %w", err)
+		}
+		code = string(data)
+	}
+	logger.Infof("nvim: running preCollectLua (%d bytes)", len(code))
+	if err := c.call(ctx, func(n *nv.Nvim) error { return n.ExecLua(code, nil) }); err != nil {
+		return fmt.Errorf("preCollectLua failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package nvim
+
+import "fmt"
+
+// ProgressFunc receives progress updates of a long collection. progress
+// increases with every update; the total is unknown.
+type ProgressFunc func(progress int, message string)
+
+// SetProgress makes the client report the progress of its refreshes and
+// collections to fn. A nil fn disables reporting.
+func (c *Client) SetProgress(fn ProgressFunc) {
+	c.progress = fn
+}
+
+// progressf reports a progress update if a ProgressFunc is set.
+func (c *Client) progressf(format string, args ...any) {
+	if c.progress == nil {
+		return
+	}
+	c.progressCount++
+	c.progress(c.progressCount, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,58 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// quickfixTitle identifies quickfix lists populated by this server, which are
+// always safe to overwrite.
+const quickfixTitle = "nvim-lsp-mcp diagnostics"
+
+//go:embed lua/set_quickfix.lua
+var setQuickfixLua string
+
+// setQuickfix populates the session's quickfix list with diagnostics so the
+// user can navigate them. A list not created by this server is only
+// overwritten when replace is set. It returns the number of entries set.
+func setQuickfix(ctx context.Context, c *Client, diags []Diagnostic, replace bool) (int, error) {
+	items := make([]map[string]any, 0, len(diags))
+	for _, d := range diags {
+		text := d.Message
+		if d.Source != "" {
+			text += fmt.Sprintf(" (%s)", d.Source)
+		}
+		if d.Code != "" {
+			text += fmt.Sprintf(" [%s]", d.Code)
+		}
+		items = append(items, map[string]any{
+			"filename": d.File,
+			"lnum":     d.Line,
+			"col":      d.Col,
+			"text":     text,
+			"type":     quickfixType(d.Severity),
+		})
+	}
+	return callResult(ctx, c, func(n *nv.Nvim, count *int) error {
+		return n.ExecLua(setQuickfixLua, count, items, quickfixTitle, replace)
+	})
+}
+
+// quickfixType maps a severity to a quickfix entry type.
+func quickfixType(severity string) string {
+	switch severity {
+	case "error":
+		return "E"
+	case "warning":
+		return "W"
+	case "info":
+		return "I"
+	case "hint":
+		return "N"
+	default:
+		return ""
+	}
+}
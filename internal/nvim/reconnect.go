@@ -0,0 +1,118 @@
+package nvim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/neovim/go-client/msgpack/rpc"
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// UnresponsiveThreshold is how long a single RPC call may take before Neovim
+// is considered blocked.
+const UnresponsiveThreshold = 30 * time.Second
+
+// ErrUnresponsive is returned when an RPC call exceeds UnresponsiveThreshold.
+var ErrUnresponsive = errors.New("nvim appears unresponsive (possibly a blocking prompt such as \"Press ENTER\")")
+
+// call runs fn against the Neovim connection. If fn fails because the
+// connection itself is gone (e.g. Neovim was restarted), it reconnects once to
+// a session for the same workspace and retries fn. A headless session is
+// owned by the client, so losing it is final. fn must not write to the
+// caller's variables; calls with a result use callResult.
+func (c *Client) call(ctx context.Context, fn func(n *nv.Nvim) error) error {
+	_, err := callResult(ctx, c, func(n *nv.Nvim, _ *struct{}) error { return fn(n) })
+	return err
+}
+
+// callResult is call for an fn decoding a result into its second argument.
+// Every attempt decodes into a value of its own, handed back only when fn
+// returns in time, so an abandoned call never writes to the caller's
+// variables.
+func callResult[T any](ctx context.Context, c *Client, fn func(n *nv.Nvim, result *T) error) (T, error) {
+	result, err := watch(ctx, c, fn)
+	if err == nil || !isConnectionError(err) || c.workspace == "" || c.method == ConnectHeadless {
+		return result, err
+	}
+	logger.Warnf("nvim: connection lost (%v), reconnecting to workspace %s", err, c.workspace)
+	if rerr := retry(ctx, ReconnectAttempts, ReconnectBaseDelay, func() error { return c.reconnect(ctx) }); rerr != nil {
+		logger.Errorf("nvim: reconnect failed: %v", rerr)
+		return result, err
+	}
+	logger.Infof("nvim: reconnected, retrying failed call")
+	return watch(ctx, c, fn)
+}
+
+// watch runs fn, giving up with ErrUnresponsive once it exceeds
+// UnresponsiveThreshold or with ctx.Err() once ctx is done. A blocked Neovim
+// never answers, so the call is abandoned rather than cancelled; its result
+// is then dropped and the zero value returned.
+func watch[T any](ctx context.Context, c *Client, fn func(n *nv.Nvim, result *T) error) (T, error) {
+	type outcome struct {
+		result T
+		err    error
+	}
+	n := c.NV
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		var result T
+		err := fn(n, &result)
+		done <- outcome{result, err}
+	}()
+
+	var zero T
+	timer := time.NewTimer(UnresponsiveThreshold)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-timer.C:
+		logger.Errorf("nvim: RPC call still running after %s, Neovim appears unresponsive", time.Since(start).Round(time.Second))
+		return zero, ErrUnresponsive
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// reconnect replaces the connection with a new one to a session whose cwd
+// matches the client's workspace.
+func (c *Client) reconnect(ctx context.Context) error {
+	fresh, err := ConnectFromEnv(ctx)
+	if err == nil {
+		cwd, cwdErr := GetCwd(ctx, fresh)
+		if cwdErr != nil || cwd != c.workspace {
+			fresh.Close()
+			fresh = nil
+		}
+	}
+	if fresh == nil {
+		fresh, err = DiscoverAndConnectByCwd(ctx, c.workspace)
+		if err != nil {
+			return fmt.Errorf("rediscover %s: %w", c.workspace, err)
+		}
+	}
+	_ = c.NV.Close()
+	c.NV = fresh.NV
+	c.addr = fresh.addr
+	c.method = fresh.method
+	return nil
+}
+
+// isConnectionError reports whether err means the RPC connection is broken
+// rather than the call itself failing.
+func isConnectionError(err error) bool {
+	return errors.Is(err, rpc.ErrClosed) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
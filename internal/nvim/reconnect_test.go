@@ -0,0 +1,42 @@
+package nvim
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+func TestWatchAbandoned(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started, release, finished := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	result, err := watch(ctx, &Client{}, func(_ *nv.Nvim, out *string) error {
+		defer close(finished)
+		close(started)
+		<-release
+		// Written after watch gave up, into the abandoned call's own value
+		*out = "late"
+		return nil
+	})
+	close(release)
+	<-finished
+	if !errors.Is(err, context.Canceled) || result != "" {
+		t.Errorf("watch() = %q, %v, want the zero value and context.Canceled", result, err)
+	}
+}
+
+func TestWatchResult(t *testing.T) {
+	result, err := watch(context.Background(), &Client{}, func(_ *nv.Nvim, out *int) error {
+		*out = 42
+		return nil
+	})
+	if err != nil || result != 42 {
+		t.Errorf("watch() = %d, %v, want 42", result, err)
+	}
+}
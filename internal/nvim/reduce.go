@@ -0,0 +1,167 @@
+package nvim
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultTestPatterns identify test files for CollectOptions.ExcludeTests.
+var DefaultTestPatterns = []string{"_test.go", ".test.", "spec.", "/tests/"}
+
+// reduceDiagnostics applies the filters of opts to diagnostics collected in
+// workspace.
+func reduceDiagnostics(diags []Diagnostic, workspace string, opts CollectOptions) []Diagnostic {
+	testPatterns := opts.TestPatterns
+	if len(testPatterns) == 0 {
+		testPatterns = DefaultTestPatterns
+	}
+
+	reduced := diags[:0]
+	for _, d := range diags {
+		// Remap first so a remapped diagnostic can pass the severity filter
+		if sev := overrideSeverity(d, opts.SeverityOverrides); sev != d.Severity {
+			d.Severity, d.SeverityLevel = sev, severityRank(sev)
+		}
+		if len(opts.Severities) > 0 && !slices.Contains(opts.Severities, d.Severity) {
+			continue
+		}
+		if !clientAllowed(d.Client, opts.IncludeClients, opts.ExcludeClients) {
+			continue
+		}
+		if opts.ExcludeTests && isTestFile(d.File, workspace, testPatterns) {
+			continue
+		}
+		if opts.messageInclude != nil && !opts.messageInclude.MatchString(d.Message) {
+			continue
+		}
+		if opts.messageExclude != nil && opts.messageExclude.MatchString(d.Message) {
+			continue
+		}
+		reduced = append(reduced, d)
+	}
+	return reduced
+}
+
+// isTestFile reports whether file contains any of patterns. The path is
+// matched relative to workspace with a leading slash, so "/tests/" also
+// matches a top-level tests directory but not a parent of the workspace.
+func isTestFile(file, workspace string, patterns []string) bool {
+	path := file
+	if rel, err := filepath.Rel(workspace, file); err == nil && !strings.HasPrefix(rel, "..") {
+		path = "/" + filepath.ToSlash(rel)
+	}
+	return slices.ContainsFunc(patterns, func(p string) bool { return p != "" && strings.Contains(path, p) })
+}
+
+// validate checks the option values that cannot be validated by their type,
+// and compiles the message filters.
+func (o *CollectOptions) validate() error {
+	if o.BufferSource != "" && !slices.Contains(BufferSources, o.BufferSource) {
+		return fmt.Errorf("invalid buffer source %q: want %s", o.BufferSource, strings.Join(BufferSources, ", "))
+	}
+	if o.Format == OutputGroupedByEdit && len(o.Edits) == 0 {
+		return fmt.Errorf("the %s output format needs edits to group by", OutputGroupedByEdit)
+	}
+	for _, e := range o.Edits {
+		if err := e.validate(); err != nil {
+			return err
+		}
+	}
+	if err := validateMultiline(o.Multiline); err != nil {
+		return err
+	}
+	for _, col := range o.Columns {
+		if !slices.Contains(TextColumns, col) {
+			return fmt.Errorf("invalid column %q: want %s", col, strings.Join(TextColumns, ", "))
+		}
+	}
+	if o.ChangedContext < 0 {
+		return fmt.Errorf("changedContext must not be negative")
+	}
+	if o.OverflowThreshold < 0 {
+		return fmt.Errorf("overflowThreshold must not be negative")
+	}
+	if o.ClientTimeout < 0 {
+		return fmt.Errorf("clientTimeout must not be negative")
+	}
+	if o.FetchShape != "" && !slices.Contains(FetchShapes, o.FetchShape) {
+		return fmt.Errorf("invalid fetch shape %q: want %s", o.FetchShape, strings.Join(FetchShapes, ", "))
+	}
+	if o.FullScan && len(o.Files) > 0 {
+		return fmt.Errorf("fullScan scans the whole workspace and cannot be combined with files")
+	}
+	for _, sev := range o.Severities {
+		if !isSeverity(sev) {
+			return fmt.Errorf("invalid severity %q: want error, warning, info or hint", sev)
+		}
+	}
+	for key, sev := range o.SeverityOverrides {
+		if !isSeverity(sev) {
+			return fmt.Errorf("invalid severity override %s=%q: want error, warning, info or hint", key, sev)
+		}
+	}
+	for _, rule := range o.FailRules {
+		if !isSeverity(rule.Severity) {
+			return fmt.Errorf("invalid fail rule %s: want severity error, warning, info or hint", rule)
+		}
+		if rule.MaxCount < 0 {
+			return fmt.Errorf("invalid fail rule %s: maxCount must not be negative", rule)
+		}
+	}
+	var err error
+	if o.messageInclude, err = compileFilter("messageInclude", o.MessageInclude); err != nil {
+		return err
+	}
+	if o.messageExclude, err = compileFilter("messageExclude", o.MessageExclude); err != nil {
+		return err
+	}
+	return nil
+}
+
+// compileFilter compiles the regular expression of the named filter, or
+// returns nil if it is empty.
+func compileFilter(name, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s pattern: %w", name, err)
+	}
+	return re, nil
+}
+
+// isSeverity reports whether s is a known severity name.
+func isSeverity(s string) bool {
+	return severityRank(s) <= 4
+}
+
+// overrideSeverity returns the remapped severity of d. A "source:code" key
+// takes precedence over a source-wide "source" key.
+func overrideSeverity(d Diagnostic, overrides map[string]string) string {
+	if len(overrides) == 0 || d.Source == "" {
+		return d.Severity
+	}
+	if d.Code != "" {
+		if sev, ok := overrides[d.Source+":"+d.Code]; ok {
+			return sev
+		}
+	}
+	if sev, ok := overrides[d.Source]; ok {
+		return sev
+	}
+	return d.Severity
+}
+
+// clientAllowed reports whether diagnostics from client pass the include and
+// exclude lists. Names are compared case-insensitively.
+func clientAllowed(client string, include, exclude []string) bool {
+	match := func(name string) bool { return strings.EqualFold(name, client) }
+	if len(include) > 0 && !slices.ContainsFunc(include, match) {
+		return false
+	}
+	return !slices.ContainsFunc(exclude, match)
+}
@@ -0,0 +1,158 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+//go:embed lua/cleanup_worktree.lua
+var cleanupWorktreeLua string
+
+// DiffDiagnostics returns the diagnostics introduced in the working copy
+// relative to the base git ref.
+//
+// The base revision is checked out into a temporary git worktree so the user's
+// working copy is never touched. Only files changed between base and the working
+// copy are diagnosed, capped at MaxFilesToReload. Diagnostics are compared by
+// file, severity, source, code and message, ignoring positions since unrelated
+// edits shift line numbers.
+func DiffDiagnostics(ctx context.Context, c *Client, base string) ([]Diagnostic, error) {
+	workspace, err := GetCwd(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	c.workspace = workspace
+
+	// Paths relative to the workspace, which may be a subdirectory of the
+	// repository; the worktree checks out the whole repository
+	relFiles, err := gitPaths(ctx, workspace, "diff", "--name-only", "--relative", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := runGit(ctx, workspace, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	if len(relFiles) == 0 {
+		logger.Infof("nvim diff: no files changed since %s", base)
+		return nil, nil
+	}
+	if len(relFiles) > MaxFilesToReload {
+		relFiles = relFiles[:MaxFilesToReload]
+		logger.Warnf("nvim diff: capped changed files to %d", MaxFilesToReload)
+	}
+
+	tmp, err := os.MkdirTemp("", "nvim-lsp-mcp-base-")
+	if err != nil {
+		return nil, err
+	}
+	// Buffer names use the resolved path (e.g. /private/var on macOS)
+	if resolved, err := filepath.EvalSymlinks(tmp); err == nil {
+		tmp = resolved
+	}
+	if _, err := runGit(ctx, workspace, "worktree", "add", "--detach", tmp, base); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+	logger.Infof("nvim diff: checked out %s into %s", base, tmp)
+
+	baseRoot := filepath.Join(tmp, prefix)
+	headFiles := existingFiles(workspace, relFiles)
+	baseFiles := existingFiles(baseRoot, relFiles)
+	defer func() {
+		// Clean up even when ctx is done, or the session keeps buffers and
+		// servers pointing at the removed worktree
+		err := c.call(context.WithoutCancel(ctx), func(n *nv.Nvim) error { return n.ExecLua(cleanupWorktreeLua, nil, tmp, baseFiles) })
+		if err != nil {
+			logger.Warnf("nvim diff: failed to clean up worktree buffers: %v", err)
+		}
+		// Use a fresh context so cleanup still runs after cancellation
+		if _, err := runGit(context.Background(), workspace, "worktree", "remove", "--force", tmp); err != nil {
+			logger.Warnf("nvim diff: failed to remove worktree %s: %v", tmp, err)
+		}
+		_ = os.RemoveAll(tmp)
+	}()
+
+	for _, files := range [][]string{headFiles, baseFiles} {
+		if len(files) == 0 {
+			continue
+		}
+		// Never force: buffers with unsaved edits keep them
+		outcome, err := callResult(ctx, c, func(n *nv.Nvim, out *refreshOutcome) error {
+			return n.ExecLua(refreshLua, out, files, false, true)
+		})
+		if err != nil {
+			logger.Warnf("nvim diff: failed to refresh diagnostics: %v", err)
+		}
+		if len(outcome.Skipped) > 0 {
+			logger.Warnf("nvim diff: skipped reloading %d buffers with unsaved changes", len(outcome.Skipped))
+		}
+	}
+
+	logger.Infof("nvim diff: waiting for LSP to reload diagnostics...")
+	if err := sleep(ctx, SettleDelay); err != nil {
+		return nil, err
+	}
+
+	bufs, err := listBuffers(ctx, c, ScopeAll)
+	if err != nil {
+		return nil, err
+	}
+	var headDiags, baseDiags []Diagnostic
+	if len(headFiles) > 0 {
+		if headDiags, err = collectBufferDiagnostics(ctx, c, bufs, CollectOptions{Files: headFiles}); err != nil {
+			return nil, err
+		}
+	}
+	if len(baseFiles) > 0 {
+		if baseDiags, err = collectBufferDiagnostics(ctx, c, bufs, CollectOptions{Files: baseFiles}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Match diagnostics as a multiset so repeated identical diagnostics count
+	seen := make(map[string]int, len(baseDiags))
+	for _, d := range baseDiags {
+		seen[diffKey(baseRoot, d)]++
+	}
+	var introduced []Diagnostic
+	for _, d := range headDiags {
+		key := diffKey(workspace, d)
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		introduced = append(introduced, d)
+	}
+	logger.Infof("nvim diff: base=%d head=%d introduced=%d", len(baseDiags), len(headDiags), len(introduced))
+	return introduced, nil
+}
+
+// existingFiles joins relFiles onto root, keeping only files that exist.
+func existingFiles(root string, relFiles []string) []string {
+	files := make([]string, 0, len(relFiles))
+	for _, rel := range relFiles {
+		abs := filepath.Join(root, rel)
+		if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+			files = append(files, abs)
+		}
+	}
+	return files
+}
+
+// diffKey identifies a diagnostic independently of its position and root.
+func diffKey(root string, d Diagnostic) string {
+	rel, err := filepath.Rel(root, d.File)
+	if err != nil {
+		rel = d.File
+	}
+	return strings.Join([]string{rel, d.Severity, d.Source, d.Code, d.Message}, "\x00")
+}
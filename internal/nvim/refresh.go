@@ -0,0 +1,45 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+)
+
+// RefreshResult lists the files a refresh reloaded and those it left alone.
+type RefreshResult struct {
+	Refreshed []string `json:"refreshed"`
+	// SkippedUnsaved lists files not reloaded because their buffers have
+	// unsaved changes.
+	SkippedUnsaved []string `json:"skippedUnsaved,omitempty"`
+	// Warnings lists operational problems of the refresh, e.g. a failure to
+	// list the changed files or the buffer the user is editing left alone.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RefreshDiagnostics reloads the given files (or the changed files when none
+// are given) and notifies LSP clients, without waiting for the servers to
+// publish or collecting anything. Pair it with a later GatherDiagnostics.
+// Without reload, only the already loaded buffers are notified, and with
+// clients only the named LSP clients are. With guard, the buffer the user is
+// editing in insert or visual mode is left alone.
+func RefreshDiagnostics(ctx context.Context, c *Client, files []string, force, reload bool, clients []string, guard bool) (*RefreshResult, error) {
+	workspace, err := GetCwd(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	c.workspace = workspace
+	c.warnings = nil
+
+	if files, err = filePaths(files); err != nil {
+		return nil, err
+	}
+	files, err = workspaceFiles(c, files, workspace, false)
+	if err != nil {
+		return nil, err
+	}
+	refreshed, skipped, err := refreshWorkspaceDiagnostics(ctx, c, files, workspace, MaxFilesToReload, force, reload, clients, guard)
+	if err != nil {
+		return nil, err
+	}
+	return &RefreshResult{Refreshed: refreshed, SkippedUnsaved: skipped, Warnings: c.warnings}, nil
+}
@@ -0,0 +1,78 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+//go:embed lua/rename_preview.lua
+var renamePreviewLua string
+
+// RenameFile is a file a rename would edit, with its number of text edits.
+type RenameFile struct {
+	File  string `json:"file"`
+	Edits int    `json:"edits"`
+}
+
+// RenamePreviewResult is the WorkspaceEdit a rename would apply.
+type RenamePreviewResult struct {
+	// Client is the LSP client that computed the rename.
+	Client string       `json:"client"`
+	Files  []RenameFile `json:"files"`
+	// Operations describes the file creations, renames and deletions of
+	// the edit, e.g. "rename /a.go -> /b.go".
+	Operations []string `json:"operations"`
+	// Changes holds the content of each edited file before and after the
+	// rename, only computed when asked for.
+	Changes []FileChange `json:"changes"`
+	// Supported is false when no attached client supports rename.
+	Supported bool `json:"supported"`
+}
+
+// Edits returns the total number of text edits of the rename.
+func (r *RenamePreviewResult) Edits() int {
+	n := 0
+	for _, f := range r.Files {
+		n += f.Edits
+	}
+	return n
+}
+
+// RenamePreview asks the first attached client supporting rename for the
+// WorkspaceEdit of renaming the symbol at the position in file to newName,
+// checking with prepareRename first when the client supports it. Nothing is
+// applied: the files and their edit counts are returned, and with changes the
+// content each file would have, computed on scratch copies.
+func RenamePreview(ctx context.Context, c *Client, file string, pos Position, newName string, changes bool) (*RenamePreviewResult, error) {
+	if strings.TrimSpace(newName) == "" {
+		return nil, fmt.Errorf("new name is required")
+	}
+	bp, err := resolvePosition(ctx, c, file, pos)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(renamePreviewLua, out, bp.Bufnr, bp.Row, bp.Col, newName, luaTimeout(ctx, RequestTimeout), changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result RenamePreviewResult
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("invalid rename result: %w", err)
+	}
+	logger.Infof("nvim: rename to %q would make %d edits in %d files", newName, result.Edits(), len(result.Files))
+	return &result, nil
+}
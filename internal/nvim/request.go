@@ -0,0 +1,42 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envLspRequest opts in to the generic lsp-request tool, which can send any
+// request, including ones with side effects such as workspace/executeCommand.
+const envLspRequest = "NVIM_LSP_MCP_LSP_REQUEST"
+
+// lspMethodPattern matches LSP method names such as textDocument/documentLink,
+// server-specific ones such as rust-analyzer/expandMacro and $/ methods.
+var lspMethodPattern = regexp.MustCompile(`^(\$|[A-Za-z0-9_.-]+)(/[A-Za-z0-9_.-]+)+$`)
+
+// LspRequestEnabled reports whether the generic lsp-request tool is enabled.
+func LspRequestEnabled() bool {
+	return os.Getenv(envLspRequest) == "1"
+}
+
+// Request sends an arbitrary LSP request to every client attached to the
+// buffer of file and returns their raw responses. textDocument is added to
+// params, and position too when pos is given.
+func Request(ctx context.Context, c *Client, file, method string, params map[string]any, pos *Position) ([]ClientResponse, error) {
+	if !lspMethodPattern.MatchString(method) {
+		return nil, fmt.Errorf("invalid LSP method %q: want e.g. textDocument/documentLink", method)
+	}
+	at := Position{Line: 1, Col: 1}
+	if pos != nil {
+		at = *pos
+	}
+	bp, err := resolvePosition(ctx, c, file, at)
+	if err != nil {
+		return nil, err
+	}
+	if pos == nil {
+		bp.Row = -1
+	}
+	return requestAt(ctx, c, bp, method, params)
+}
@@ -0,0 +1,34 @@
+package nvim
+
+import "testing"
+
+func TestLspMethodPattern(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"textDocument/documentLink", true},
+		{"workspace/executeCommand", true},
+		{"rust-analyzer/expandMacro", true},
+		{"clangd/switchSourceHeader", true},
+		{"typescript.tsserverRequest/x", true},
+		{"gopls/v2_test", true},
+		{"$/progress", true},
+		{"$/cancelRequest", true},
+		{"textDocument", false},
+		{"", false},
+		{"/hover", false},
+		{"textDocument/", false},
+		{"textDocument//hover", false},
+		{"$", false},
+		{"$/", false},
+		{"text Document/hover", false},
+		{"textDocument/hover\n", false},
+		{"a$/b", false},
+	}
+	for _, tt := range tests {
+		if got := lspMethodPattern.MatchString(tt.method); got != tt.want {
+			t.Errorf("lspMethodPattern.MatchString(%q) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
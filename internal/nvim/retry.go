@@ -0,0 +1,60 @@
+package nvim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Retry policies, tuned in one place.
+const (
+	// DialAttempts and DialBaseDelay retry dialing a discovered socket, which
+	// may be briefly busy while Neovim starts.
+	DialAttempts  = 2
+	DialBaseDelay = 100 * time.Millisecond
+
+	// ReconnectAttempts and ReconnectBaseDelay retry finding the session
+	// again after the connection drops, giving a restarting Neovim time to
+	// come back.
+	ReconnectAttempts  = 3
+	ReconnectBaseDelay = 500 * time.Millisecond
+)
+
+// retry calls fn up to attempts times until it succeeds, doubling the delay
+// between attempts from base. It stops early when fn returns an error wrapped
+// with permanent, returning the unwrapped error, or when ctx is done while
+// waiting, returning ctx.Err() wrapped with the last error. Otherwise it
+// returns the last error.
+func retry(ctx context.Context, attempts int, base time.Duration, fn func() error) error {
+	var err error
+	delay := base
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		var p permanentError
+		if errors.As(err, &p) {
+			return p.err
+		}
+		if attempt >= attempts {
+			return err
+		}
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return fmt.Errorf("%w (last attempt: %w)", sleepErr, err)
+		}
+		delay *= 2
+	}
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (p permanentError) Error() string { return p.err.Error() }
+
+func (p permanentError) Unwrap() error { return p.err }
+
+// permanent wraps err so retry gives up immediately.
+func permanent(err error) error {
+	return permanentError{err}
+}
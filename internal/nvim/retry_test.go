@@ -0,0 +1,87 @@
+package nvim
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryAttemptsAndBackoff(t *testing.T) {
+	errBusy := errors.New("busy")
+	base := 10 * time.Millisecond
+	var calls []time.Time
+	start := time.Now()
+	err := retry(context.Background(), 3, base, func() error {
+		calls = append(calls, time.Now())
+		return errBusy
+	})
+	if !errors.Is(err, errBusy) {
+		t.Fatalf("retry() = %v, want %v", err, errBusy)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("fn called %d times, want 3", len(calls))
+	}
+	// The delays double: base before the second attempt, 2*base before the third
+	if d := calls[1].Sub(calls[0]); d < base {
+		t.Errorf("first delay %s, want at least %s", d, base)
+	}
+	if d := calls[2].Sub(calls[1]); d < 2*base {
+		t.Errorf("second delay %s, want at least %s", d, 2*base)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retry took %s", elapsed)
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	calls := 0
+	err := retry(context.Background(), 5, time.Millisecond, func() error {
+		if calls++; calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("retry() = %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestRetryPermanent(t *testing.T) {
+	errFatal := errors.New("refused")
+	calls := 0
+	err := retry(context.Background(), 5, time.Hour, func() error {
+		calls++
+		return permanent(errFatal)
+	})
+	if err != errFatal {
+		t.Fatalf("retry() = %v, want the unwrapped %v", err, errFatal)
+	}
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errBusy := errors.New("busy")
+	calls := 0
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	err := retry(ctx, 5, time.Hour, func() error {
+		calls++
+		return errBusy
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("retry() = %v, want %v", err, context.Canceled)
+	}
+	if !errors.Is(err, errBusy) {
+		t.Errorf("retry() = %v, want it to wrap the last error", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retry returned after %s, want right after the cancellation", elapsed)
+	}
+}
@@ -0,0 +1,138 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// MaxScanFiles bounds the number of files a workspace scan loads.
+var MaxScanFiles = 1000
+
+//go:embed lua/scan_open.lua
+var scanOpenLua string
+
+//go:embed lua/scan_close.lua
+var scanCloseLua string
+
+// scannedBuffer is a buffer loaded by scanOpenLua.
+type scannedBuffer struct {
+	File   string `json:"file"`
+	Bufnr  int    `json:"bufnr"`
+	Opened bool   `json:"opened"`
+}
+
+// scanWorkspace collects the diagnostics of every source file in workspace,
+// open or not. Files are loaded in batches of MaxFilesToReload, given
+// SettleDelay for their LSP clients to check them, collected and then wiped
+// again, except for buffers that were loaded before.
+func scanWorkspace(ctx context.Context, c *Client, workspace string, opts CollectOptions) ([]Diagnostic, error) {
+	files, err := scanFiles(ctx, workspace, opts.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > MaxScanFiles {
+		c.warnf("workspace scan capped to the first %d of %d files", MaxScanFiles, len(files))
+		files = files[:MaxScanFiles]
+	}
+	logger.Infof("nvim: scanning %d workspace files", len(files))
+
+	var diags []Diagnostic
+	for start := 0; start < len(files); start += MaxFilesToReload {
+		batch := files[start:min(start+MaxFilesToReload, len(files))]
+		c.progressf("scanning files %d-%d of %d", start+1, start+len(batch), len(files))
+		more, err := scanBatch(ctx, c, batch, opts)
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, more...)
+	}
+	return diags, nil
+}
+
+// scanBatch loads, collects and wipes one batch of files.
+func scanBatch(ctx context.Context, c *Client, files []string, opts CollectOptions) ([]Diagnostic, error) {
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.ExecLua(scanOpenLua, out, files) })
+	if err != nil {
+		return nil, err
+	}
+	var bufs []scannedBuffer
+	if err := json.Unmarshal([]byte(jsonStr), &bufs); err != nil {
+		return nil, fmt.Errorf("invalid scan result: %w", err)
+	}
+	var opened []int
+	for _, b := range bufs {
+		if b.Opened {
+			opened = append(opened, b.Bufnr)
+		}
+	}
+	defer func() {
+		if len(opened) == 0 {
+			return
+		}
+		if err := c.call(context.WithoutCancel(ctx), func(n *nv.Nvim) error { return n.ExecLua(scanCloseLua, nil, opened) }); err != nil {
+			c.warnf("failed to wipe %d scanned buffers: %v", len(opened), err)
+		}
+	}()
+
+	if len(opened) > 0 {
+		if err := sleep(ctx, SettleDelay); err != nil {
+			return nil, err
+		}
+	}
+	bufnrs := make([]int, len(bufs))
+	for i, b := range bufs {
+		bufnrs[i] = b.Bufnr
+	}
+	opts.Files = files
+	return collectBufferDiagnostics(ctx, c, bufnrs, opts)
+}
+
+// scanFiles lists the files of workspace to scan: those tracked by git or,
+// outside a git repository, all files outside hidden directories, limited to
+// extensions when given.
+func scanFiles(ctx context.Context, workspace string, extensions []string) ([]string, error) {
+	var files []string
+	if tracked := gitTrackedFiles(ctx, workspace); tracked != nil {
+		// Tracked files may have been deleted from the working copy
+		for f := range tracked {
+			if info, err := os.Stat(f); err == nil && info.Mode().IsRegular() {
+				files = append(files, f)
+			}
+		}
+	} else {
+		err := filepath.WalkDir(workspace, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != workspace && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(extensions) > 0 {
+		files = slices.DeleteFunc(files, func(f string) bool { return !hasExtension(f, extensions) })
+	}
+	slices.Sort(files)
+	return files, nil
+}
@@ -0,0 +1,80 @@
+package nvim
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+const (
+	// ClientPollInterval is how often waitForClients polls LSP progress
+	ClientPollInterval = 250 * time.Millisecond
+
+	// MinClientSettle is how long waitForClients waits at least, so servers
+	// have begun reporting progress for the refresh before idleness counts
+	MinClientSettle = time.Second
+)
+
+// waitForClients replaces the fixed SettleDelay with a wait bounded per LSP
+// client: it returns as soon as no client has $/progress work pending, and
+// stops waiting for a client once it has been busy for timeout. A client that
+// has not reported any progress yet may simply not support it, so as long as
+// one is attached the wait lasts SettleDelay, or timeout if shorter. The
+// clients still busy at that point, and those without progress when timeout
+// cut their SettleDelay short, are returned, so the diagnostics collected next
+// can be reported as partial for them instead of one slow server stalling
+// the results of the fast ones.
+func waitForClients(ctx context.Context, c *Client, timeout time.Duration) ([]string, error) {
+	start := time.Now()
+	var timedOut []string
+	reported := make(map[string]bool)
+	for {
+		if err := sleep(ctx, ClientPollInterval); err != nil {
+			return nil, err
+		}
+		status, err := lspProgress(ctx, c)
+		if err != nil {
+			c.warnf("failed to query LSP progress, not waiting per client: %v", err)
+			return nil, nil
+		}
+		elapsed := time.Since(start)
+		waiting := 0
+		for _, name := range status.BusyClients {
+			reported[name] = true
+			switch {
+			case slices.Contains(timedOut, name):
+			case elapsed >= timeout:
+				logger.Warnf("nvim: LSP client %s still busy after %s, collecting without waiting for it", name, timeout)
+				timedOut = append(timedOut, name)
+			default:
+				waiting++
+			}
+		}
+		var quiet []string
+		for _, name := range status.ClientNames {
+			if !reported[name] && !slices.Contains(quiet, name) {
+				quiet = append(quiet, name)
+			}
+		}
+		settle := MinClientSettle
+		if len(quiet) > 0 {
+			settle = max(settle, SettleDelay)
+		}
+		if waiting == 0 && elapsed >= min(settle, timeout) {
+			if elapsed < SettleDelay {
+				for _, name := range quiet {
+					logger.Warnf("nvim: LSP client %s reported no progress within %s, collecting without waiting for it", name, timeout)
+					timedOut = append(timedOut, name)
+				}
+			}
+			return timedOut, nil
+		}
+		if waiting > 0 {
+			c.progressf("waiting for %d busy LSP clients", waiting)
+		} else {
+			c.progressf("waiting for %d LSP clients that report no progress", len(quiet))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package nvim
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForClients(t *testing.T) {
+	defer func(d time.Duration) { SettleDelay = d }(SettleDelay)
+	SettleDelay = 1500 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		clients     []string
+		busyPolls   int // polls during which gopls reports progress
+		wantPartial []string
+		minWait     time.Duration
+		maxWait     time.Duration
+	}{
+		{
+			name:      "all clients report progress",
+			timeout:   5 * time.Second,
+			clients:   []string{"gopls"},
+			busyPolls: 1,
+			minWait:   MinClientSettle,
+			maxWait:   SettleDelay,
+		},
+		{
+			name:      "client without progress gets the settle delay",
+			timeout:   5 * time.Second,
+			clients:   []string{"gopls", "typos"},
+			busyPolls: 1,
+			minWait:   SettleDelay,
+			maxWait:   SettleDelay + time.Second,
+		},
+		{
+			name:        "timeout cuts the settle delay short",
+			timeout:     time.Second,
+			clients:     []string{"gopls", "typos"},
+			busyPolls:   100,
+			wantPartial: []string{"gopls", "typos"},
+			minWait:     time.Second,
+			maxWait:     SettleDelay,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls atomic.Int32
+			c := newFakeClient(t, "fake", fakeSession{progress: func() lspStatus {
+				status := lspStatus{Clients: len(tt.clients), ClientNames: tt.clients}
+				if int(polls.Add(1)) <= tt.busyPolls {
+					status.Busy = true
+					status.BusyClients = []string{"gopls"}
+				}
+				return status
+			}})
+
+			start := time.Now()
+			partial, err := waitForClients(context.Background(), c, tt.timeout)
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(partial, tt.wantPartial) {
+				t.Errorf("partial = %v, want %v", partial, tt.wantPartial)
+			}
+			if elapsed < tt.minWait || elapsed > tt.maxWait {
+				t.Errorf("waited %s, want between %s and %s", elapsed, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	nv "github.com/neovim/go-client/nvim"
+)
+
+// DefaultContextLines is the number of lines shown on each side of a position.
+const DefaultContextLines = 5
+
+// SourceContext returns the lines around the position in file, read from its
+// buffer (loading it if necessary), with 1-based line numbers. The line of the
+// position is marked with ">".
+func SourceContext(ctx context.Context, c *Client, file string, pos Position, radius int) (string, error) {
+	bp, err := resolvePosition(ctx, c, file, pos)
+	if err != nil {
+		return "", err
+	}
+	start := max(bp.Row-radius, 0)
+	lines, err := callResult(ctx, c, func(n *nv.Nvim, out *[]string) error {
+		return n.Call("nvim_buf_get_lines", out, bp.Bufnr, start, bp.Row+radius+1, false)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	width := len(fmt.Sprint(start + len(lines)))
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		marker := " "
+		if start+i == bp.Row {
+			marker = ">"
+		}
+		out = append(out, fmt.Sprintf("%s%*d | %s", marker, width, start+i+1, line))
+	}
+	return strings.Join(out, "\n"), nil
+}
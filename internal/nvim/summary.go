@@ -0,0 +1,59 @@
+package nvim
+
+import "fmt"
+
+// SeverityCounts counts diagnostics per severity.
+type SeverityCounts struct {
+	Error   int `json:"error"`
+	Warning int `json:"warning"`
+	Info    int `json:"info"`
+	Hint    int `json:"hint"`
+}
+
+// add counts n diagnostics of severity. Unknown severities are not counted.
+func (c *SeverityCounts) add(severity string, n int) {
+	switch severityRank(severity) {
+	case 1:
+		c.Error += n
+	case 2:
+		c.Warning += n
+	case 3:
+		c.Info += n
+	case 4:
+		c.Hint += n
+	}
+}
+
+// String renders the counts as "E:3 W:5 I:0 H:1".
+func (c SeverityCounts) String() string {
+	return fmt.Sprintf("E:%d W:%d I:%d H:%d", c.Error, c.Warning, c.Info, c.Hint)
+}
+
+// highest returns the most severe severity counted, or "" for none.
+func (c SeverityCounts) highest() string {
+	switch {
+	case c.Error > 0:
+		return "error"
+	case c.Warning > 0:
+		return "warning"
+	case c.Info > 0:
+		return "info"
+	case c.Hint > 0:
+		return "hint"
+	}
+	return ""
+}
+
+// FormatSummaryLine renders only the number of diagnostics per severity, as
+// "E:3 W:5 I:0 H:1", for status indicators. Diagnostics omitted to fit the
+// limit or budget are counted too.
+func FormatSummaryLine(r *Report) string {
+	var counts SeverityCounts
+	for _, d := range r.Diagnostics {
+		counts.add(d.Severity, 1)
+	}
+	for _, g := range r.Omitted {
+		counts.add(g.Severity, g.Count)
+	}
+	return counts.String()
+}
@@ -0,0 +1,160 @@
+package nvim
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+//go:embed lua/find_symbol.lua
+var findSymbolLua string
+
+// SymbolCandidate is a declaration of a symbol found by findSymbol, with a
+// 0-based row and byte column.
+type SymbolCandidate struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+}
+
+// SymbolInfo is the hover documentation and definitions of a symbol.
+type SymbolInfo struct {
+	Symbol SymbolCandidate
+	// Line and Col are the 1-based position the symbol was found at.
+	Line int
+	Col  int
+	// Hover is the hover documentation as markdown, empty if there is none.
+	Hover string
+	// Definitions are the definition locations as "file:line:col", with
+	// 1-based lines and the servers' character offsets plus one as columns.
+	Definitions []string
+}
+
+// LookupSymbol finds the symbol called name in file, by its document symbols
+// or else by the first whole-word occurrence of name in the text, and returns
+// its hover documentation and definitions. When several document symbols
+// match, it fails listing them so the caller can pick a position.
+func LookupSymbol(ctx context.Context, c *Client, file, name string) (*SymbolInfo, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("symbol name is required")
+	}
+	bp, err := resolvePosition(ctx, c, file, Position{Line: 1, Col: 1})
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := findSymbol(ctx, c, bp.Bufnr, name)
+	if err != nil {
+		return nil, err
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("symbol %q not found in %s", name, file)
+	case 1:
+	default:
+		lines := make([]string, 0, len(candidates))
+		for _, cand := range candidates {
+			lines = append(lines, fmt.Sprintf("  %s:%d:%d %s (%s)", file, cand.Row+1, cand.Col+1, cand.Name, cand.Kind))
+		}
+		return nil, fmt.Errorf("symbol %q is ambiguous, %d candidates; use a position-based tool with one of them:\n%s",
+			name, len(candidates), strings.Join(lines, "\n"))
+	}
+
+	sym := candidates[0]
+	bp.Row, bp.Col = sym.Row, sym.Col
+	info := &SymbolInfo{Symbol: sym, Line: sym.Row + 1, Col: sym.Col + 1}
+	if info.Hover, err = hoverAt(ctx, c, bp); err != nil {
+		return nil, err
+	}
+	responses, err := requestAt(ctx, c, bp, "textDocument/definition", nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range responses {
+		if len(resp.Error) > 0 {
+			logger.Warnf("nvim: definition error from %s: %s", resp.Client, resp.Error)
+			continue
+		}
+		for _, loc := range definitionLocations(resp.Result) {
+			if !slices.Contains(info.Definitions, loc) {
+				info.Definitions = append(info.Definitions, loc)
+			}
+		}
+	}
+	return info, nil
+}
+
+// findSymbol returns the candidate declarations of name in the buffer, in
+// position order without duplicates.
+func findSymbol(ctx context.Context, c *Client, bufnr int, name string) ([]SymbolCandidate, error) {
+	release, err := c.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	jsonStr, err := callResult(ctx, c, func(n *nv.Nvim, out *string) error {
+		return n.ExecLua(findSymbolLua, out, bufnr, name, luaTimeout(ctx, RequestTimeout))
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Candidates []SymbolCandidate `json:"candidates"`
+		Source     string            `json:"source"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("invalid symbol search result: %w", err)
+	}
+	logger.Infof("nvim: found %d candidates for symbol %q via %s", len(result.Candidates), name, result.Source)
+	// Several clients may report the same symbol
+	var candidates []SymbolCandidate
+	for _, cand := range result.Candidates {
+		if n := len(candidates); n == 0 || candidates[n-1].Row != cand.Row || candidates[n-1].Col != cand.Col {
+			candidates = append(candidates, cand)
+		}
+	}
+	return candidates, nil
+}
+
+// definitionLocations flattens a textDocument/definition result, which may
+// be a Location, a Location array or a LocationLink array, into
+// "file:line:col" strings.
+func definitionLocations(raw json.RawMessage) []string {
+	type lspLocation struct {
+		URI            string    `json:"uri"`
+		Range          *LspRange `json:"range"`
+		TargetURI      string    `json:"targetUri"`
+		TargetSelRange *LspRange `json:"targetSelectionRange"`
+	}
+	var list []lspLocation
+	if err := json.Unmarshal(raw, &list); err != nil {
+		var single lspLocation
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil
+		}
+		list = []lspLocation{single}
+	}
+	var locs []string
+	for _, l := range list {
+		uri, rng := l.URI, l.Range
+		if l.TargetURI != "" {
+			uri, rng = l.TargetURI, l.TargetSelRange
+		}
+		if uri == "" || rng == nil {
+			continue
+		}
+		file := uri
+		if paths, err := filePaths([]string{uri}); err == nil {
+			file = paths[0]
+		}
+		locs = append(locs, fmt.Sprintf("%s:%d:%d", file, rng.Start.Line+1, rng.Start.Character+1))
+	}
+	return locs
+}
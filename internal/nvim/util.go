@@ -2,28 +2,47 @@ package nvim
 
 import (
 	"context"
+	"time"
+
+	nv "github.com/neovim/go-client/nvim"
 )
 
-// GetCwd returns the Neovim process current working directory.
+// GetCwd returns the Neovim process current working directory, retrying once
+// if the connection fails.
 func GetCwd(ctx context.Context, c *Client) (string, error) {
-	cwdCh := make(chan string, 1)
-	errCh := make(chan error, 1)
-
-	go func() {
-		var cwd string
-		if err := c.NV.Eval("getcwd()", &cwd); err != nil {
-			errCh <- err
-			return
+	var cwd string
+	err := retry(ctx, DialAttempts, DialBaseDelay, func() error {
+		var err error
+		cwd, err = callResult(ctx, c, func(n *nv.Nvim, out *string) error { return n.Eval("getcwd()", out) })
+		// Only a broken connection is worth another try
+		if err != nil && !isConnectionError(err) {
+			return permanent(err)
 		}
-		cwdCh <- cwd
-	}()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return cwd, nil
+}
 
+// luaTimeout returns the milliseconds a Lua wait may block: limit, or less
+// when ctx has an earlier deadline. ExecLua cannot be cancelled once running,
+// so waits inside Lua get the remaining budget instead. The result is at
+// least 1 so Lua never treats it as "no timeout".
+func luaTimeout(ctx context.Context, limit time.Duration) int64 {
+	if deadline, ok := ctx.Deadline(); ok {
+		limit = min(limit, time.Until(deadline))
+	}
+	return max(limit.Milliseconds(), 1)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
 	select {
-	case cwd := <-cwdCh:
-		return cwd, nil
-	case err := <-errCh:
-		return "", err
+	case <-time.After(d):
+		return nil
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,54 @@
+package nvim
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	nv "github.com/neovim/go-client/nvim"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// envSkipVersionCheck bypasses the MinNeovimVersion gate, for patched or
+// development builds that report a misleading version.
+const envSkipVersionCheck = "NVIM_LSP_MCP_SKIP_VERSION_CHECK"
+
+// neovimVersion returns the major, minor and patch version of the session.
+func neovimVersion(ctx context.Context, c *Client) ([3]int, error) {
+	version, err := callResult(ctx, c, func(n *nv.Nvim, out *[]int) error {
+		return n.ExecLua("local v = vim.version() return { v.major, v.minor, v.patch }", out)
+	})
+	if err != nil {
+		return [3]int{}, err
+	}
+	if len(version) != 3 {
+		return [3]int{}, fmt.Errorf("unexpected version %v", version)
+	}
+	return [3]int{version[0], version[1], version[2]}, nil
+}
+
+// versionSupported reports whether version is at least MinNeovimVersion.
+func versionSupported(version [3]int) bool {
+	return version[0] > MinNeovimVersion[0] || version[0] == MinNeovimVersion[0] && version[1] >= MinNeovimVersion[1]
+}
+
+// CheckVersion fails when the session runs a Neovim older than
+// MinNeovimVersion, whose Lua APIs the helpers rely on. With
+// NVIM_LSP_MCP_SKIP_VERSION_CHECK=1 it only logs a warning, and an
+// incompatible API then surfaces as the error of the operation itself.
+func CheckVersion(ctx context.Context, c *Client) error {
+	version, err := neovimVersion(ctx, c)
+	if err != nil {
+		return fmt.Errorf("failed to read Neovim version: %w", err)
+	}
+	if versionSupported(version) {
+		return nil
+	}
+	if os.Getenv(envSkipVersionCheck) == "1" {
+		logger.Warnf("nvim: Neovim %d.%d.%d is older than %d.%d, continuing because %s=1", version[0], version[1], version[2], MinNeovimVersion[0], MinNeovimVersion[1], envSkipVersionCheck)
+		return nil
+	}
+	return fmt.Errorf("running Neovim %d.%d.%d is older than the required %d.%d; upgrade Neovim, or set %s=1 to try anyway",
+		version[0], version[1], version[2], MinNeovimVersion[0], MinNeovimVersion[1], envSkipVersionCheck)
+}
@@ -0,0 +1,20 @@
+package nvim
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+)
+
+// warnf logs an operational warning and records it on the client, so that
+// conditions making the result incomplete (files skipped, git failures,
+// unanswered requests) end up in Report.Warnings. Repeated warnings, e.g.
+// from rechecks, are recorded once.
+func (c *Client) warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	logger.Warnf("nvim: %s", msg)
+	if !slices.Contains(c.warnings, msg) {
+		c.warnings = append(c.warnings, msg)
+	}
+}
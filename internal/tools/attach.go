@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// Environment variable naming the workspace used when a call omits it.
+const envDefaultWorkspace = "NVIM_LSP_MCP_DEFAULT_WORKSPACE"
+
+// resolveWorkspace returns workspace, or when it is empty the default
+// workspace from the environment, or else the cwd of the only running Neovim
+// session. An explicit workspace is always authoritative.
+func resolveWorkspace(ctx context.Context, workspace string) (string, *mcp.CallToolResult) {
+	if strings.TrimSpace(workspace) != "" {
+		return workspace, nil
+	}
+	if def := os.Getenv(envDefaultWorkspace); strings.TrimSpace(def) != "" {
+		logger.Infof("using default workspace %s from %s", def, envDefaultWorkspace)
+		return def, nil
+	}
+
+	sessions := nvim.DiscoverSessions(ctx)
+	cwds := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		if !slices.Contains(cwds, s.Cwd) {
+			cwds = append(cwds, s.Cwd)
+		}
+	}
+	switch len(cwds) {
+	case 0:
+		return "", mcp.NewToolResultErrorf("workspace is required: pass workspace or set %s (no running Neovim session found)", envDefaultWorkspace)
+	case 1:
+		logger.Infof("using workspace %s of the only Neovim session", cwds[0])
+		return cwds[0], nil
+	default:
+		lines := make([]string, 0, len(sessions))
+		for _, s := range sessions {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", s.Cwd, s.Addr))
+		}
+		return "", mcp.NewToolResultErrorf("workspace is required: %d Neovim sessions are running, pass one of their cwds as workspace:\n%s",
+			len(sessions), strings.Join(lines, "\n"))
+	}
+}
+
+// repoRoot returns the git top level of workspace when useRepoRoot is set,
+// for callers passing a subdirectory of the repository they mean.
+func repoRoot(ctx context.Context, workspace string, useRepoRoot bool) string {
+	if !useRepoRoot {
+		return workspace
+	}
+	if root := nvim.RepoRoot(ctx, workspace); root != workspace {
+		logger.Infof("using repository root %s as workspace for %s", root, workspace)
+		return root
+	}
+	return workspace
+}
+
+// attach connects to the Neovim session for workspace and validates its cwd.
+// On failure it returns a tool error result to hand back to the client.
+func attach(ctx context.Context, workspace string) (*nvim.Client, *mcp.CallToolResult) {
+	cli, err := nvim.ConnectFromEnv(ctx)
+	if err == nil {
+		// $NVIM is set in every :terminal, so the session from the environment
+		// may well be another project's editor
+		if cwd, cwdErr := nvim.GetCwd(ctx, cli); cwdErr != nil || cwd != workspace {
+			logger.Infof("Neovim session at %s (%s) has cwd %s, not %s; discovering instead",
+				cli.Connection().Addr, cli.Connection().Method, cwd, workspace)
+			cli.Close()
+			err = fmt.Errorf("session from the environment does not match %s", workspace)
+		}
+	}
+	if err != nil {
+		// Fallback to auto-discovery: find a Neovim whose cwd matches workspace
+		cli, err = nvim.DiscoverAndConnectByCwd(ctx, workspace)
+		if err != nil && nvim.LenientDiscovery {
+			cli, err = nvim.DiscoverAndConnectContaining(ctx, workspace)
+		}
+		if err != nil && nvim.HeadlessEnabled() {
+			logger.Infof("no Neovim session for %s (%v), starting a headless one", workspace, err)
+			cli, err = nvim.StartHeadless(ctx, workspace)
+		}
+		if err != nil {
+			return nil, mcp.NewToolResultErrorFromErr("failed to attach to Neovim", err)
+		}
+	}
+
+	// Validate that the Neovim session cwd matches the requested workspace
+	cwd, err := nvim.GetCwd(ctx, cli)
+	if err != nil {
+		cli.Close()
+		return nil, mcp.NewToolResultErrorFromErr("failed to read Neovim cwd", err)
+	}
+	if cwd != workspace && cli.LenientMatch() {
+		logger.Warnf("using Neovim session with cwd %s for workspace %s (lenient discovery)", cwd, workspace)
+	} else if cwd != workspace {
+		cli.Close()
+		return nil, mcp.NewToolResultErrorf("nvim cwd mismatch: expected %s, got %s", workspace, cwd)
+	}
+	if err := nvim.CheckVersion(ctx, cli); err != nil {
+		cli.Close()
+		return nil, mcp.NewToolResultError(err.Error())
+	}
+	return cli, nil
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// CursorDiagnosticsArgs defines the structured input schema for the cursor-diagnostics tool.
+type CursorDiagnosticsArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+}
+
+// CursorDiagnosticsHandler returns the MCP tool handler for the "cursor-diagnostics" tool.
+func CursorDiagnosticsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args CursorDiagnosticsArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	cr, diags, err := nvim.DiagnosticsAtCursor(ctx, cli)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to read diagnostics at cursor", err), nil
+	}
+
+	where := fmt.Sprintf("cursor at line %d, col %d", cr.StartLine, cr.StartCol)
+	if cr.Selection {
+		where = fmt.Sprintf("selection from line %d to %d", cr.StartLine, cr.EndLine)
+	}
+	if len(diags) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("no diagnostics at the %s", where)), nil
+	}
+	lines := make([]string, 0, len(diags))
+	for _, d := range diags {
+		lines = append(lines, d.String())
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// DiagnosticsResourceTemplate is the URI template of the diagnostics resource.
+// The reserved expansion lets the absolute workspace path keep its slashes,
+// e.g. nvim-lsp:///home/me/project/diagnostics.
+const DiagnosticsResourceTemplate = "nvim-lsp://{+workspace}/diagnostics"
+
+// DiagnosticsResourceHandler returns the MCP resource handler for the
+// workspace diagnostics resource, rendered as the JSON output of read-lints.
+func DiagnosticsResourceHandler(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	var workspace string
+	// Template variables arrive as a string or, from the URI matcher, a list
+	switch v := req.Params.Arguments["workspace"].(type) {
+	case string:
+		workspace = v
+	case []string:
+		if len(v) > 0 {
+			workspace = v[0]
+		}
+	}
+	if workspace == "" {
+		return nil, errors.New("workspace is required in the resource URI")
+	}
+
+	cli, errResult := attach(ctx, workspace)
+	if errResult != nil {
+		return nil, fmt.Errorf("%s", resultText(errResult))
+	}
+	defer cli.Close()
+
+	report, err := nvim.GatherDiagnostics(ctx, cli, nvim.CollectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect diagnostics: %w", err)
+	}
+	output, err := nvim.FormatJSON(report)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "application/json",
+			Text:     output,
+		},
+	}, nil
+}
+
+// resultText returns the text of a tool result, to reuse tool errors outside
+// tool handlers.
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return "unknown error"
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// DoctorArgs defines the structured input schema for the doctor tool.
+type DoctorArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+}
+
+// DoctorHandler returns the MCP tool handler for the "doctor" tool.
+func DoctorHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args DoctorArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+
+	checks := nvim.Doctor(ctx, args.Workspace)
+	lines := make([]string, 0, len(checks))
+	for _, check := range checks {
+		status := "PASS"
+		if !check.OK {
+			status = "FAIL"
+		}
+		line := fmt.Sprintf("[%s] %s", status, check.Name)
+		if check.Detail != "" {
+			line += ": " + check.Detail
+		}
+		lines = append(lines, line)
+		if !check.OK && check.Hint != "" {
+			lines = append(lines, "       hint: "+check.Hint)
+		}
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
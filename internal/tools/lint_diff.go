@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LintDiffArgs defines the structured input schema for the lint-diff tool.
+type LintDiffArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	Base      string `json:"base" jsonschema_description:"Git ref to compare against, e.g. main or origin/main. Only diagnostics introduced since this ref are returned." jsonschema:"required"`
+}
+
+// LintDiffHandler returns the MCP tool handler for the "lint-diff" tool.
+func LintDiffHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LintDiffArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	if strings.TrimSpace(args.Base) == "" {
+		return mcp.NewToolResultError("base is required"), nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	diags, err := nvim.DiffDiagnostics(ctx, cli, args.Base)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to diff diagnostics", err), nil
+	}
+
+	return mcp.NewToolResultText(nvim.FormatText(&nvim.Report{Diagnostics: diags})), nil
+}
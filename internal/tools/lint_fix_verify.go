@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LintFixVerifyArgs defines the structured input schema for the lint-fix-verify tool.
+type LintFixVerifyArgs struct {
+	Workspace     string   `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	Files         []string `json:"files,omitempty" jsonschema_description:"List of absolute file paths to fix, if empty, fallsback to the changed files (staged and unstaged) via git diff."`
+	MaxIterations int      `json:"maxIterations,omitempty" jsonschema_description:"Maximum fix and re-check rounds (default and maximum 3)"`
+}
+
+// LintFixVerifyHandler returns the MCP tool handler for the "lint-fix-verify" tool.
+func LintFixVerifyHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LintFixVerifyArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+	cli.SetProgress(progressReporter(ctx, req))
+
+	result, err := nvim.FixAndVerify(ctx, cli, nvim.CollectOptions{Files: args.Files}, args.MaxIterations)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fix diagnostics", err), nil
+	}
+	logger.Infof("lint-fix-verify: %d fixes in %d rounds, %d diagnostics remain", len(result.Fixed), result.Iterations, len(result.Report.Diagnostics))
+
+	lines := []string{fmt.Sprintf("applied %d fixes in %d rounds", len(result.Fixed), result.Iterations)}
+	for _, f := range result.Fixed {
+		lines = append(lines, "  "+f)
+	}
+	lines = append(lines, "")
+	if len(result.Report.Diagnostics) == 0 {
+		lines = append(lines, "no diagnostics remain")
+	} else {
+		lines = append(lines, fmt.Sprintf("%d diagnostics remain:", len(result.Report.Diagnostics)), nvim.FormatText(result.Report))
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspCapabilitiesArgs defines the structured input schema for the lsp-capabilities tool.
+type LspCapabilitiesArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+}
+
+// LspCapabilitiesHandler returns the MCP tool handler for the "lsp-capabilities" tool.
+func LspCapabilitiesHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspCapabilitiesArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	clients, err := nvim.ListClients(ctx, cli)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to list LSP clients", err), nil
+	}
+
+	data, err := json.MarshalIndent(clients, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to encode LSP clients", err), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspCodeActionsArgs defines the structured input schema for the lsp-code-actions tool.
+type LspCodeActionsArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	PositionArgs
+	Kind       string `json:"kind,omitempty" jsonschema_description:"Only request code actions of this kind, e.g. quickfix or source.fixAll"`
+	Apply      int    `json:"apply,omitempty" jsonschema_description:"1-based index of the listed code action to apply. When omitted the available actions are only listed."`
+	ApplyTitle string `json:"applyTitle,omitempty" jsonschema_description:"Title of the code action to apply, as an alternative to apply that is stable across runs. An exact match wins; otherwise the only title containing it (ignoring case) is used."`
+}
+
+// LspCodeActionsHandler returns the MCP tool handler for the "lsp-code-actions" tool.
+func LspCodeActionsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspCodeActionsArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	if args.Apply < 0 {
+		return mcp.NewToolResultError("apply must be a 1-based index"), nil
+	}
+	if args.Apply > 0 && args.ApplyTitle != "" {
+		return mcp.NewToolResultError("pass either apply or applyTitle, not both"), nil
+	}
+	pos, errResult := args.position(args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	result, err := nvim.CodeActions(ctx, cli, args.File, pos, args.Kind, args.Apply, args.ApplyTitle)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to run code actions", err), nil
+	}
+
+	if result.Applied == "" {
+		if len(result.Actions) == 0 {
+			return mcp.NewToolResultText("No code actions available at this position"), nil
+		}
+		lines := make([]string, 0, len(result.Actions))
+		for i, a := range result.Actions {
+			line := fmt.Sprintf("%d. %s (%s)", i+1, a.Title, a.Client)
+			if a.Kind != "" {
+				line = fmt.Sprintf("%d. [%s] %s (%s)", i+1, a.Kind, a.Title, a.Client)
+			}
+			lines = append(lines, line)
+		}
+		return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+	}
+
+	out := fmt.Sprintf("Applied %q, changed %d files", result.Applied, len(result.Changes))
+	if len(result.Unsaved) > 0 {
+		out += fmt.Sprintf("\nNot written (buffers had unsaved changes): %s", strings.Join(result.Unsaved, ", "))
+	}
+	if diff := nvim.UnifiedDiff(result.Changes); diff != "" {
+		out += "\n\n" + diff
+	}
+	return mcp.NewToolResultText(out), nil
+}
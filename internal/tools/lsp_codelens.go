@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspCodeLensArgs defines the structured input schema for the lsp-codelens tool.
+type LspCodeLensArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	File      string `json:"file" jsonschema_description:"Absolute path of a file within the workspace" jsonschema:"required"`
+	Execute   int    `json:"execute,omitempty" jsonschema_description:"1-based index of the listed code lens whose command to execute. Requires confirm, since commands such as running tests have side effects. When omitted the lenses are only listed."`
+	Confirm   bool   `json:"confirm,omitempty" jsonschema_description:"Confirm executing the code lens selected by execute"`
+}
+
+// LspCodeLensHandler returns the MCP tool handler for the "lsp-codelens" tool.
+func LspCodeLensHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspCodeLensArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	if errResult := validateFile(args.Workspace, args.File); errResult != nil {
+		return errResult, nil
+	}
+	if args.Execute < 0 {
+		return mcp.NewToolResultError("execute must be a 1-based index"), nil
+	}
+	if args.Execute > 0 && !args.Confirm {
+		return mcp.NewToolResultError("executing a code lens runs its command; pass confirm=true to proceed"), nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	result, err := nvim.CodeLenses(ctx, cli, args.File, args.Execute)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get code lenses", err), nil
+	}
+
+	if !result.Supported {
+		return mcp.NewToolResultText("No attached LSP client supports code lens for this file"), nil
+	}
+	if result.Executed != "" {
+		return mcp.NewToolResultText(fmt.Sprintf("Executed %q", result.Executed)), nil
+	}
+	if len(result.Lenses) == 0 {
+		return mcp.NewToolResultText("No code lenses in this file"), nil
+	}
+	lines := make([]string, 0, len(result.Lenses))
+	for i, l := range result.Lenses {
+		title := l.Title
+		if title == "" {
+			title = "(no command)"
+		}
+		lines = append(lines, fmt.Sprintf("%d. %d:%d-%d:%d %s (%s)", i+1, l.Line, l.Col, l.EndLine, l.EndCol, title, l.Client))
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspContextArgs defines the structured input schema for the lsp-context tool.
+type LspContextArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	PositionArgs
+	Lines *int `json:"lines,omitempty" jsonschema_description:"Number of lines to show before and after the position (default 5)"`
+}
+
+// LspContextHandler returns the MCP tool handler for the "lsp-context" tool.
+func LspContextHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspContextArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	pos, errResult := args.position(args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	radius := nvim.DefaultContextLines
+	if args.Lines != nil {
+		if *args.Lines < 0 {
+			return mcp.NewToolResultError("lines must not be negative"), nil
+		}
+		radius = *args.Lines
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	text, err := nvim.SourceContext(ctx, cli, args.File, pos, radius)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to read source context", err), nil
+	}
+	return mcp.NewToolResultText(text), nil
+}
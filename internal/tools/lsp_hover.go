@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspHoverArgs defines the structured input schema for the lsp-hover tool.
+type LspHoverArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	PositionArgs
+}
+
+// LspHoverHandler returns the MCP tool handler for the "lsp-hover" tool.
+func LspHoverHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspHoverArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	pos, errResult := args.position(args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	text, err := nvim.Hover(ctx, cli, args.File, pos)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get hover", err), nil
+	}
+	if text == "" {
+		return mcp.NewToolResultText("No hover information at this position"), nil
+	}
+	return mcp.NewToolResultText(text), nil
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspMessagesArgs defines the structured input schema for the lsp-messages tool.
+type LspMessagesArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	Limit     int    `json:"limit,omitempty" jsonschema_description:"Maximum number of the most recent server messages to return. Defaults to (and is capped at) 200."`
+	LogLines  int    `json:"logLines,omitempty" jsonschema_description:"Also return this many of the last lines of Neovim's LSP log file, which covers messages from before the capture started"`
+	Stop      bool   `json:"stop,omitempty" jsonschema_description:"Stop capturing messages instead, restoring the session's original message handlers and dropping the captured messages"`
+}
+
+// LspMessagesHandler returns the MCP tool handler for the "lsp-messages" tool.
+func LspMessagesHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspMessagesArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	if args.Limit < 0 || args.LogLines < 0 {
+		return mcp.NewToolResultError("limit and logLines must not be negative"), nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	if args.Stop {
+		if err := nvim.StopMessages(ctx, cli); err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to stop capturing LSP messages", err), nil
+		}
+		return mcp.NewToolResultText("Stopped capturing LSP server messages"), nil
+	}
+
+	result, err := nvim.LspMessages(ctx, cli, args.Limit, args.LogLines)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to read LSP messages", err), nil
+	}
+
+	var lines []string
+	if len(result.Messages) == 0 {
+		lines = append(lines, "No LSP server messages captured yet (capture starts with the first lsp-messages call on the session)")
+	}
+	for _, m := range result.Messages {
+		lines = append(lines, fmt.Sprintf("%s [%s] %s %s: %s",
+			time.Unix(m.Time, 0).Format(time.TimeOnly), m.Type, m.Client, m.Method, m.Message))
+	}
+	if len(result.Log) > 0 {
+		lines = append(lines, "", "--- "+result.LogPath+" ---")
+		lines = append(lines, result.Log...)
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspRenamePreviewArgs defines the structured input schema for the
+// lsp-rename-preview tool.
+type LspRenamePreviewArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	PositionArgs
+	NewName string `json:"newName" jsonschema_description:"New name for the symbol at the position" jsonschema:"required"`
+	Diff    bool   `json:"diff,omitempty" jsonschema_description:"Also return a unified diff of the changes the rename would make"`
+}
+
+// LspRenamePreviewHandler returns the MCP tool handler for the
+// "lsp-rename-preview" tool.
+func LspRenamePreviewHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspRenamePreviewArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	pos, errResult := args.position(args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	if strings.TrimSpace(args.NewName) == "" {
+		return mcp.NewToolResultError("newName is required"), nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	result, err := nvim.RenamePreview(ctx, cli, args.File, pos, args.NewName, args.Diff)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to compute rename", err), nil
+	}
+
+	if !result.Supported {
+		return mcp.NewToolResultText("No attached LSP client supports rename for this file"), nil
+	}
+	if len(result.Files) == 0 && len(result.Operations) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Renaming to %q would change nothing (%s)", args.NewName, result.Client)), nil
+	}
+	lines := []string{fmt.Sprintf("Renaming to %q would make %d edits in %d files (%s, not applied):",
+		args.NewName, result.Edits(), len(result.Files), result.Client)}
+	for _, f := range result.Files {
+		lines = append(lines, fmt.Sprintf("  %s: %d edits", f.File, f.Edits))
+	}
+	for _, op := range result.Operations {
+		lines = append(lines, "  "+op)
+	}
+	if args.Diff && len(result.Changes) > 0 {
+		lines = append(lines, "", strings.TrimRight(nvim.UnifiedDiff(result.Changes), "\n"))
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
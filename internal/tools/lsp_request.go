@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspRequestArgs defines the structured input schema for the lsp-request tool.
+type LspRequestArgs struct {
+	Workspace string         `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	Method    string         `json:"method" jsonschema_description:"LSP method to send, e.g. textDocument/documentLink, textDocument/codeLens or a server-specific one such as rust-analyzer/expandMacro" jsonschema:"required"`
+	Params    map[string]any `json:"params,omitempty" jsonschema_description:"Request params. textDocument is filled in, and position too when line or offset is given."`
+	PositionArgs
+}
+
+// LspRequestHandler returns the MCP tool handler for the "lsp-request" tool.
+func LspRequestHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspRequestArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	var pos *nvim.Position
+	if args.Line > 0 || args.Offset != nil {
+		p, errResult := args.position(args.Workspace)
+		if errResult != nil {
+			return errResult, nil
+		}
+		pos = &p
+	} else if errResult := validateFile(args.Workspace, args.File); errResult != nil {
+		return errResult, nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	responses, err := nvim.Request(ctx, cli, args.File, args.Method, args.Params, pos)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to send "+args.Method, err), nil
+	}
+	if len(responses) == 0 {
+		return mcp.NewToolResultText("No LSP client answered " + args.Method), nil
+	}
+	data, err := json.Marshal(responses)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to encode responses", err), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
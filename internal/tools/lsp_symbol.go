@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// LspSymbolArgs defines the structured input schema for the lsp-symbol tool.
+type LspSymbolArgs struct {
+	Workspace string `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	File      string `json:"file" jsonschema_description:"Absolute path of a file within the workspace" jsonschema:"required"`
+	Name      string `json:"name" jsonschema_description:"Name of the symbol, e.g. NewServer or Start for a method (*Server).Start" jsonschema:"required"`
+}
+
+// LspSymbolHandler returns the MCP tool handler for the "lsp-symbol" tool.
+func LspSymbolHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args LspSymbolArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = workspace
+	if errResult := validateFile(args.Workspace, args.File); errResult != nil {
+		return errResult, nil
+	}
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+
+	info, err := nvim.LookupSymbol(ctx, cli, args.File, args.Name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to look up symbol", err), nil
+	}
+
+	lines := []string{fmt.Sprintf("%s (%s) at %s:%d:%d", info.Symbol.Name, info.Symbol.Kind, args.File, info.Line, info.Col)}
+	if info.Hover != "" {
+		lines = append(lines, "", info.Hover)
+	}
+	if len(info.Definitions) > 0 {
+		lines = append(lines, "", "Definitions:")
+		for _, d := range info.Definitions {
+			lines = append(lines, "  "+d)
+		}
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
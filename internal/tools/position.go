@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// PositionArgs addresses a position in a file for the LSP position tools,
+// either by 1-based line/col or by a 0-based byte offset.
+type PositionArgs struct {
+	File   string `json:"file" jsonschema_description:"Absolute path of a file within the workspace" jsonschema:"required"`
+	Line   int    `json:"line,omitempty" jsonschema_description:"1-based line number. Required unless offset is given."`
+	Col    int    `json:"col,omitempty" jsonschema_description:"1-based byte column within the line. Defaults to 1."`
+	Offset *int   `json:"offset,omitempty" jsonschema_description:"0-based byte offset into the file, as an alternative to line/col"`
+}
+
+// position validates the args against workspace and converts them to an
+// nvim.Position. On failure it returns a tool error result.
+func (p PositionArgs) position(workspace string) (nvim.Position, *mcp.CallToolResult) {
+	if errResult := validateFile(workspace, p.File); errResult != nil {
+		return nvim.Position{}, errResult
+	}
+	if p.Offset != nil {
+		return nvim.Position{Offset: p.Offset}, nil
+	}
+	if p.Line < 1 {
+		return nvim.Position{}, mcp.NewToolResultError("line (>= 1) or offset is required")
+	}
+	col := p.Col
+	if col < 1 {
+		col = 1
+	}
+	return nvim.Position{Line: p.Line, Col: col}, nil
+}
+
+// validateFile checks that file is an absolute path within workspace.
+func validateFile(workspace, file string) *mcp.CallToolResult {
+	if strings.TrimSpace(file) == "" {
+		return mcp.NewToolResultError("file is required")
+	}
+	if !filepath.IsAbs(file) {
+		return mcp.NewToolResultErrorf("file %s must be an absolute path", file)
+	}
+	if !nvim.WithinWorkspace(file, workspace) {
+		return mcp.NewToolResultErrorf("file %s is outside workspace %s", file, workspace)
+	}
+	return nil
+}
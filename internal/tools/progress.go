@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// progressReporter returns a ProgressFunc sending MCP progress notifications
+// for req, or nil when the client did not ask for progress.
+func progressReporter(ctx context.Context, req mcp.CallToolRequest) nvim.ProgressFunc {
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil
+	}
+	token := req.Params.Meta.ProgressToken
+	return func(progress int, message string) {
+		err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       message,
+		})
+		if err != nil {
+			logger.Warnf("failed to send progress notification: %v", err)
+		}
+	}
+}
+
+// sharedProgress returns a function giving each workspace of a call a
+// ProgressFunc that forwards to report, prefixed with the workspace. The
+// workspaces share one counter, so the progress sent on the call's single
+// token keeps increasing while they are collected concurrently. It returns
+// nil ProgressFuncs when report is nil.
+func sharedProgress(report nvim.ProgressFunc) func(workspace string) nvim.ProgressFunc {
+	var mu sync.Mutex
+	count := 0
+	return func(workspace string) nvim.ProgressFunc {
+		if report == nil {
+			return nil
+		}
+		return func(_ int, message string) {
+			mu.Lock()
+			defer mu.Unlock()
+			count++
+			report(count, workspace+": "+message)
+		}
+	}
+}
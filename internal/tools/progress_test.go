@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedProgress(t *testing.T) {
+	var got []int
+	var messages []string
+	progress := sharedProgress(func(progress int, message string) {
+		got = append(got, progress)
+		messages = append(messages, message)
+	})
+
+	var wg sync.WaitGroup
+	for _, ws := range []string{"/a", "/b", "/c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report := progress(ws)
+			// Each client counts from 1 on its own
+			for i := 1; i <= 10; i++ {
+				report(i, "step")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(got) != 30 {
+		t.Fatalf("got %d updates, want 30", len(got))
+	}
+	for i, p := range got {
+		if p != i+1 {
+			t.Fatalf("progress = %v, want 1 to 30 in order", got)
+		}
+	}
+	for _, m := range messages {
+		if !strings.HasSuffix(m, ": step") || !strings.HasPrefix(m, "/") {
+			t.Errorf("message %q not prefixed with its workspace", m)
+		}
+	}
+
+	if sharedProgress(nil)("/a") != nil {
+		t.Error("sharedProgress(nil) gave a non-nil ProgressFunc")
+	}
+}
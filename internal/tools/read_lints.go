@@ -2,7 +2,9 @@ package tools
 
 import (
 	"context"
-	"strings"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
@@ -11,10 +13,109 @@ import (
 )
 
 // ReadLintsArgs defines the structured input schema for the read-lints tool.
-// Only an existing Neovim session is used; NVIM_LISTEN_ADDRESS must be set.
+// The session is the one named by NVIM_LISTEN_ADDRESS or $NVIM when its cwd
+// is the workspace, else one discovered by cwd (see attach), or a headless
+// Neovim when NVIM_LSP_MCP_HEADLESS=1 and none matches.
 type ReadLintsArgs struct {
-	Workspace string   `json:"workspace" jsonschema_description:"Absolute workspace path" jsonschema:"required"`
-	Files     []string `json:"files,omitempty" jsonschema_description:"List of absolute file paths to refresh diagnostics for, if empty, fallsback to refreshing changed files (staged and unstaged) via git diff."`
+	Workspace          string            `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	Workspaces         []string          `json:"workspaces,omitempty" jsonschema_description:"Absolute paths of several workspaces to collect diagnostics from in one call, each from its own Neovim session and with the other options applied to each. Results are grouped per workspace; a workspace that fails is reported in its group without failing the others. Replaces workspace."`
+	UseRepoRoot        bool              `json:"useRepoRoot,omitempty" jsonschema_description:"Resolve workspace to the top level of the git repository containing it (git rev-parse --show-toplevel) before connecting, for when a subdirectory was passed. Falls back to workspace outside a git repository."`
+	Files              []string          `json:"files,omitempty" jsonschema_description:"List of absolute file paths (or file:// URIs) to refresh diagnostics for, if empty, fallsback to refreshing changed files (staged and unstaged) via git diff."`
+	Scope              string            `json:"scope,omitempty" jsonschema_description:"Buffers to collect diagnostics from: all (default) buffers, those visible in the current tab, or the current window's buffer. Falls back to all if the narrowed scope yields nothing." jsonschema:"enum=all,enum=tab,enum=window"`
+	BufferSource       string            `json:"bufferSource,omitempty" jsonschema_description:"Kinds of buffers to collect from, within scope: all (default, every buffer including unlisted ones loaded by the refresh), listed (buffers shown by :ls), loaded (buffers in memory) or args (files in the argument list)" jsonschema:"enum=all,enum=listed,enum=loaded,enum=args"`
+	OutputFormat       string            `json:"outputFormat,omitempty" jsonschema_description:"Output format: text (default, one diagnostic per line), json, github (GitHub Actions annotations), markdown (a table), grouped-by-code (grouped per source:code rule, most frequent first), grouped-by-severity (errors first, under a header per severity), grouped-by-edit (under the edit from edits each diagnostic overlaps, the rest under other), summary-line (only the counts per severity, e.g. E:3 W:5 I:0 H:1) or lsp (a JSON array of {uri, diagnostics} with LSP Diagnostic objects, to feed LSP tooling)" jsonschema:"enum=text,enum=json,enum=github,enum=markdown,enum=grouped-by-code,enum=grouped-by-severity,enum=grouped-by-edit,enum=summary-line,enum=lsp"`
+	Edits              []nvim.Edit       `json:"edits,omitempty" jsonschema_description:"Line ranges changed by your edits ({file: absolute path, startLine, endLine}, 1-based, inclusive), to attribute diagnostics to with the grouped-by-edit output"`
+	FetchShape         string            `json:"fetchShape,omitempty" jsonschema_description:"Shape diagnostics are read from Neovim in: diagnostic (default, vim.diagnostic.get items) or qflist (vim.diagnostic.toqflist entries, which carry no source, code or client)" jsonschema:"enum=diagnostic,enum=qflist"`
+	Columns            []string          `json:"columns,omitempty" jsonschema_description:"Fields of each text output line, tab-separated in this order, chosen from file, line, col, severity, source, code and message. Defaults to the file:line:col: SEVERITY: message (source) [code] layout."`
+	Multiline          string            `json:"multiline,omitempty" jsonschema_description:"How text output renders messages spanning several lines (rustc, TypeScript): collapse (default) joins the lines with messageSeparator, indent keeps them with continuation lines indented under the first. json output keeps the full message." jsonschema:"enum=collapse,enum=indent"`
+	MessageSeparator   string            `json:"messageSeparator,omitempty" jsonschema_description:"Separator joining the lines of a collapsed multiline message (default ' / ')"`
+	OmitMissingCol     bool              `json:"omitMissingCol,omitempty" jsonschema_description:"Render diagnostics that have no column without one (file:line: ...) instead of with column 1, marked colMissing in json output"`
+	IncludeContext     bool              `json:"includeContext,omitempty" jsonschema_description:"In json output, include each diagnostic's buffer filetype and attached LSP client names"`
+	Verbose            bool              `json:"verbose,omitempty" jsonschema_description:"With files, also report how many LSP clients (and which) are attached to each requested file, to tell whether a file type is supported when a file returns nothing"`
+	CheckFixable       bool              `json:"checkFixable,omitempty" jsonschema_description:"Probe each returned diagnostic (up to 50) for an available code action and mark it [fixable]. Slower; use it to prioritize auto-fixable issues."`
+	FixableHints       bool              `json:"fixableHints,omitempty" jsonschema_description:"Mark diagnostics [fixable] when the server says in their data payload that a quick fix exists (e.g. ruff, Deno lint), without the code action round-trips of checkFixable. Servers without such hints leave diagnostics unmarked."`
+	IncludeLspRange    bool              `json:"includeLspRange,omitempty" jsonschema_description:"In json output, include each diagnostic's original LSP range (0-based line/character in the server's encoding), to feed positions back to an LSP without conversion"`
+	IncludeData        bool              `json:"includeData,omitempty" jsonschema_description:"In json output, include the opaque LSP data payload servers attach to diagnostics for resolving code actions"`
+	Force              bool              `json:"force,omitempty" jsonschema_description:"Reload buffers from disk even if they have unsaved changes. By default such buffers are skipped and reported to protect the user's in-progress edits."`
+	ReloadWhileEditing bool              `json:"reloadWhileEditing,omitempty" jsonschema_description:"Reload the buffer the user is currently editing in insert, replace, visual or select mode too. By default it is left alone, with a warning, so the refresh does not disturb their typing."`
+	ReloadBuffers      *bool             `json:"reloadBuffers,omitempty" jsonschema_description:"Load and reload buffers from disk before notifying LSP clients. Set to false when buffers are kept in sync with disk (e.g. autoread) to only send didSave for already loaded buffers. Defaults to true."`
+	PreCollectLua      string            `json:"preCollectLua,omitempty" jsonschema_description:"Lua code, or the absolute path of a .lua file, to run in the Neovim session before the refresh, e.g. to enable diagnostics or start a server. Requires NVIM_LSP_MCP_PRE_COLLECT_LUA=1 on the server."`
+	RefreshClients     []string          `json:"refreshClients,omitempty" jsonschema_description:"Only notify these LSP clients (by name, e.g. gopls) of the refresh, so only the relevant servers re-check the files. Empty means all clients. Diagnostics of the other clients are still collected."`
+	EditedFileClients  bool              `json:"editedFileClients,omitempty" jsonschema_description:"Only return diagnostics produced by the LSP clients attached to the requested files (or the changed files when files is omitted), dropping noise such as spell checkers or markdown linters on other open buffers"`
+	IncludeClients     []string          `json:"includeClients,omitempty" jsonschema_description:"Only return diagnostics produced by these LSP clients (by name, e.g. gopls). Finer-grained than filtering by source."`
+	ExcludeClients     []string          `json:"excludeClients,omitempty" jsonschema_description:"Drop diagnostics produced by these LSP clients (by name, e.g. efm)"`
+	ClientTimeoutMs    int               `json:"clientTimeoutMs,omitempty" jsonschema_description:"Instead of the fixed settle delay, wait until no LSP client reports progress, but at most this many milliseconds for each busy client. Clients that time out are reported as partial, so one slow server does not hold back the others."`
+	RecheckAttempts    int               `json:"recheckAttempts,omitempty" jsonschema_description:"If the result is empty while LSP servers still report work in progress, re-poll up to this many times (2s apart) before concluding there are no diagnostics"`
+	ExcludeTests       bool              `json:"excludeTests,omitempty" jsonschema_description:"Drop diagnostics in test files, e.g. while fixing production code"`
+	TestPatterns       []string          `json:"testPatterns,omitempty" jsonschema_description:"Substrings of the workspace-relative path (with a leading /) identifying test files for excludeTests. Defaults to _test.go, .test., spec. and /tests/."`
+	Limit              int               `json:"limit,omitempty" jsonschema_description:"Maximum number of diagnostics to return. Errors are kept first, then warnings, info and hints; the omitted remainder is summarized by file and severity."`
+	Budget             int               `json:"budget,omitempty" jsonschema_description:"Maximum size of the diagnostics in characters. Errors are kept first, then warnings, info and hints; the omitted remainder is summarized by file and severity."`
+	OverflowThreshold  int               `json:"overflowThreshold,omitempty" jsonschema_description:"When there are more diagnostics than this, return only this many (the most severe first) and write the full set as JSON to overflowPath, referenced in the output"`
+	OverflowPath       string            `json:"overflowPath,omitempty" jsonschema_description:"File the full set is written to with overflowThreshold, absolute or relative to the workspace. Defaults to .nvim-lsp-mcp-diagnostics.json in the workspace root."`
+	Quickfix           bool              `json:"quickfix,omitempty" jsonschema_description:"Also populate the user's Neovim quickfix list with the diagnostics so they can navigate them"`
+	ReplaceQuickfix    bool              `json:"replaceQuickfix,omitempty" jsonschema_description:"Allow quickfix to overwrite a quickfix list the user created. Without it such a list is left untouched."`
+	Severities         []string          `json:"severities,omitempty" jsonschema_description:"Only return diagnostics with these severities: error, warning, info, hint. Applied after severityOverrides."`
+	SeverityOverrides  map[string]string `json:"severityOverrides,omitempty" jsonschema_description:"Remap severities, keyed by source (e.g. staticcheck) or source:code (e.g. eslint:no-unused-vars), to error, warning, info or hint. source:code keys take precedence over source keys."`
+	MessageInclude     string            `json:"messageInclude,omitempty" jsonschema_description:"Only return diagnostics whose raw message matches this regular expression (Go RE2 syntax, case-sensitive; prefix (?i) to ignore case)"`
+	MessageExclude     string            `json:"messageExclude,omitempty" jsonschema_description:"Drop diagnostics whose raw message matches this regular expression (Go RE2 syntax, case-sensitive; prefix (?i) to ignore case)"`
+	StrictPaths        bool              `json:"strictPaths,omitempty" jsonschema_description:"Fail with an error listing any files outside the workspace instead of silently skipping them"`
+	FailOnErrors       bool              `json:"failOnErrors,omitempty" jsonschema_description:"Mark the result as failed if any error-severity diagnostic remains after filtering"`
+	FailRules          []nvim.FailRule   `json:"failRules,omitempty" jsonschema_description:"Per-source gate rules: fail if more than maxCount (default 0) diagnostics from source (* for any) are at least as severe as severity"`
+	PathBase           string            `json:"pathBase,omitempty" jsonschema_description:"Report paths relative to this directory (absolute, or relative to the workspace), e.g. the package being worked on. Must be within the workspace."`
+	CleanMessage       *bool             `json:"cleanMessage,omitempty" jsonschema_description:"When no diagnostics are found, say so with the number of buffers and files checked instead of returning empty text output. Defaults to true; set to false to get empty output."`
+	IncludeWarnings    *bool             `json:"includeWarnings,omitempty" jsonschema_description:"Append the operational warnings of the collection (files skipped, git failures, unanswered requests) that may make the result incomplete. Defaults to true."`
+	StripPrefix        string            `json:"stripPrefix,omitempty" jsonschema_description:"Remove this leading prefix (e.g. src/) from the rendered file paths when they start with it, for brevity. Display only; applied after pathBase."`
+	ExportPath         string            `json:"exportPath,omitempty" jsonschema_description:"Also write the diagnostics as JSON to this absolute file path (atomically replaced) for external tools watching it. Defaults to NVIM_LSP_MCP_EXPORT_PATH."`
+	IncludeMeta        bool              `json:"includeMeta,omitempty" jsonschema_description:"Include the Neovim cwd and git HEAD commit the diagnostics were collected against in an output header"`
+	ChangedLinesOnly   bool              `json:"changedLinesOnly,omitempty" jsonschema_description:"Only return diagnostics on the lines your changes touched (git diff hunks against HEAD, staged or not), excluding pre-existing issues on unchanged lines of changed files. Untracked files count as wholly changed."`
+	ChangedContext     int               `json:"changedContext,omitempty" jsonschema_description:"With changedLinesOnly, also keep diagnostics within this many lines around each changed hunk (default 0)"`
+	GitTrackedOnly     bool              `json:"gitTrackedOnly,omitempty" jsonschema_description:"Only return diagnostics in files tracked by git, dropping generated or ignored files that happen to be open. Ignored outside a git repository."`
+	MergeSessions      bool              `json:"mergeSessions,omitempty" jsonschema_description:"Also collect from every other Neovim session whose cwd is the workspace (e.g. a second window on the same project), deduplicating the diagnostics"`
+	FreshOnly          bool              `json:"freshOnly,omitempty" jsonschema_description:"Only return diagnostics published after the refresh, dropping markers that were already present right after the files were loaded"`
+	Extensions         []string          `json:"extensions,omitempty" jsonschema_description:"Only collect diagnostics from files with these extensions, e.g. [\".go\", \"ts\"]. Empty means all files."`
+	ModifiedSince      int64             `json:"modifiedSince,omitempty" jsonschema_description:"Unix timestamp in seconds: only collect diagnostics from files modified (per their mtime) after it, e.g. files touched since the task started. Combines with files and the git selection; files that no longer exist are skipped."`
+	FullScan           bool              `json:"fullScan,omitempty" jsonschema_description:"Scan the whole workspace, not just open buffers: every git-tracked file (limited to extensions when given) is loaded in batches, checked and unloaded again, up to 1000 files. Slow; use it for an on-demand full project lint. Cannot be combined with files."`
+	SkipBufferPrefixes []string          `json:"skipBufferPrefixes,omitempty" jsonschema_description:"Additional buffer name prefixes of plugin buffers to skip. Buffers named with a URI scheme other than file:// (oil://, fugitive://, ...) are always skipped."`
+}
+
+// Environment variable naming the default file read-lints exports to.
+const envExportPath = "NVIM_LSP_MCP_EXPORT_PATH"
+
+// readLintsDefaults holds the server-wide defaults for omitted arguments.
+var readLintsDefaults struct {
+	severities   []string
+	outputFormat string
+}
+
+// SetReadLintsDefaults sets the severities filter and output format used
+// when a read-lints call omits them.
+func SetReadLintsDefaults(severities []string, outputFormat string) {
+	readLintsDefaults.severities = severities
+	readLintsDefaults.outputFormat = outputFormat
+}
+
+// modifiedSince converts a unix timestamp argument to a time, treating zero
+// as unset.
+func modifiedSince(unix int64) time.Time {
+	if unix <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
+
+// withCleanMessage prefixes output with a message confirming that the check
+// ran and found nothing, so an empty result cannot be mistaken for a failure.
+// Formats that always render something, json, lsp and summary-line, are
+// left alone.
+func withCleanMessage(output, workspace, format string, report *nvim.Report) string {
+	if len(report.Diagnostics) > 0 || len(report.Omitted) > 0 || format == nvim.OutputJSON || format == nvim.OutputLSP || format == nvim.OutputSummaryLine {
+		return output
+	}
+	msg := fmt.Sprintf("No diagnostics found in %s (checked %d buffers, %d files)", workspace, report.BuffersChecked, report.FilesRefreshed)
+	if output == "" {
+		return msg
+	}
+	return msg + "\n\n" + output
 }
 
 // ReadLintsHandler returns the MCP tool handler for the "read-lints" tool.
@@ -24,33 +125,28 @@ func ReadLintsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallTo
 	if err := req.BindArguments(&args); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-
-	if strings.TrimSpace(args.Workspace) == "" {
-		return mcp.NewToolResultError("workspace is required"), nil
+	if len(args.Severities) == 0 {
+		args.Severities = readLintsDefaults.severities
 	}
-
-	cli, err := nvim.ConnectFromEnv(ctx)
-	if err != nil {
-		// Fallback to auto-discovery: find a Neovim whose cwd matches workspace
-		cli, err = nvim.DiscoverAndConnectByCwd(ctx, args.Workspace)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("failed to attach to Neovim", err), nil
-		}
+	if args.OutputFormat == "" {
+		args.OutputFormat = readLintsDefaults.outputFormat
 	}
-	defer cli.Close()
-
-	// Validate that the Neovim session cwd matches the requested workspace
-	cwd, err := nvim.GetCwd(ctx, cli)
-	if err != nil {
-		return mcp.NewToolResultErrorFromErr("failed to read Neovim cwd", err), nil
+	if len(args.Workspaces) > 0 {
+		return readLintsWorkspaces(ctx, req, args), nil
 	}
-	if cwd != args.Workspace {
-		return mcp.NewToolResultErrorf("nvim cwd mismatch: expected %s, got %s", args.Workspace, cwd), nil
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
 	}
+	args.Workspace = repoRoot(ctx, workspace, args.UseRepoRoot)
 
-	output, err := nvim.CollectDiagnostics(ctx, cli, args.Files)
-	if err != nil {
-		return mcp.NewToolResultErrorFromErr("failed to collect diagnostics", err), nil
+	output, gateFailed, errResult := readLints(ctx, args, progressReporter(ctx, req))
+	if errResult != nil {
+		return errResult, nil
+	}
+	if gateFailed {
+		return mcp.NewToolResultError(output), nil
 	}
 	if output == "" {
 		logger.Warnf("no diagnostics returned from Neovim")
@@ -59,3 +155,95 @@ func ReadLintsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallTo
 
 	return mcp.NewToolResultText(output), nil
 }
+
+// readLints collects and formats the diagnostics of args.Workspace, which
+// must already be resolved, reporting its progress to progress. It reports
+// whether the lint gate failed; on other failures it returns a tool error
+// result instead.
+func readLints(ctx context.Context, args ReadLintsArgs, progress nvim.ProgressFunc) (string, bool, *mcp.CallToolResult) {
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return "", false, errResult
+	}
+	defer cli.Close()
+	cli.SetProgress(progress)
+
+	report, err := nvim.GatherDiagnostics(ctx, cli, nvim.CollectOptions{
+		Files:              args.Files,
+		Scope:              args.Scope,
+		BufferSource:       args.BufferSource,
+		Format:             args.OutputFormat,
+		Edits:              args.Edits,
+		Columns:            args.Columns,
+		Multiline:          args.Multiline,
+		MessageSeparator:   args.MessageSeparator,
+		FetchShape:         args.FetchShape,
+		IncludeContext:     args.IncludeContext,
+		OmitMissingCol:     args.OmitMissingCol,
+		IncludeData:        args.IncludeData,
+		IncludeLspRange:    args.IncludeLspRange,
+		CheckFixable:       args.CheckFixable,
+		FixableHints:       args.FixableHints,
+		ReportFileClients:  args.Verbose,
+		Force:              args.Force,
+		NoReload:           args.ReloadBuffers != nil && !*args.ReloadBuffers,
+		RefreshClients:     args.RefreshClients,
+		ReloadWhileEditing: args.ReloadWhileEditing,
+		PreCollectLua:      args.PreCollectLua,
+		EditedFileClients:  args.EditedFileClients,
+		IncludeClients:     args.IncludeClients,
+		ExcludeClients:     args.ExcludeClients,
+		ClientTimeout:      time.Duration(args.ClientTimeoutMs) * time.Millisecond,
+		RecheckAttempts:    args.RecheckAttempts,
+		ExcludeTests:       args.ExcludeTests,
+		TestPatterns:       args.TestPatterns,
+		Limit:              args.Limit,
+		Budget:             args.Budget,
+		OverflowThreshold:  args.OverflowThreshold,
+		OverflowPath:       args.OverflowPath,
+		Quickfix:           args.Quickfix,
+		ReplaceQuickfix:    args.ReplaceQuickfix,
+		Severities:         args.Severities,
+		SeverityOverrides:  args.SeverityOverrides,
+		MessageInclude:     args.MessageInclude,
+		MessageExclude:     args.MessageExclude,
+		StrictPaths:        args.StrictPaths,
+		FailOnErrors:       args.FailOnErrors,
+		FailRules:          args.FailRules,
+		IncludeMeta:        args.IncludeMeta,
+		PathBase:           args.PathBase,
+		StripPrefix:        args.StripPrefix,
+		SkipBufferPrefixes: args.SkipBufferPrefixes,
+		Extensions:         args.Extensions,
+		ChangedLinesOnly:   args.ChangedLinesOnly,
+		ChangedContext:     args.ChangedContext,
+		GitTrackedOnly:     args.GitTrackedOnly,
+		FreshOnly:          args.FreshOnly,
+		MergeSessions:      args.MergeSessions,
+		FullScan:           args.FullScan,
+		ModifiedSince:      modifiedSince(args.ModifiedSince),
+	})
+	if err != nil {
+		return "", false, mcp.NewToolResultErrorFromErr("failed to collect diagnostics", err)
+	}
+	if args.IncludeWarnings != nil && !*args.IncludeWarnings {
+		report.Warnings = nil
+	}
+	if args.ExportPath == "" {
+		args.ExportPath = os.Getenv(envExportPath)
+	}
+	if args.ExportPath != "" {
+		if err := nvim.ExportJSON(report, args.ExportPath); err != nil {
+			logger.Errorf("failed to export diagnostics to %s: %v", args.ExportPath, err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("failed to export diagnostics to %s: %v", args.ExportPath, err))
+		}
+	}
+	output, err := nvim.Format(report, args.OutputFormat)
+	if err != nil {
+		return "", false, mcp.NewToolResultErrorFromErr("failed to format diagnostics", err)
+	}
+	if args.CleanMessage == nil || *args.CleanMessage {
+		output = withCleanMessage(output, args.Workspace, args.OutputFormat, report)
+	}
+	return output, report.Gate != nil && report.Gate.Failed, nil
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/logger"
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// MaxParallelWorkspaces bounds how many workspaces of a read-lints call with
+// workspaces are collected at the same time.
+const MaxParallelWorkspaces = 4
+
+// workspaceResult is the outcome of read-lints for one of several
+// workspaces. Report holds the JSON output in json and lsp output; Error is
+// set instead when the workspace could not be collected.
+type workspaceResult struct {
+	Workspace  string          `json:"workspace"`
+	Report     json.RawMessage `json:"report,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	GateFailed bool            `json:"gateFailed,omitempty"`
+
+	output string
+}
+
+// readLintsWorkspaces runs read-lints for each of args.Workspaces, at most
+// MaxParallelWorkspaces at once, and returns the results grouped per
+// workspace in the order given. A workspace failing is reported in its group
+// rather than failing the call; only a failed lint gate makes the result an
+// error, as for a single workspace.
+func readLintsWorkspaces(ctx context.Context, req mcp.CallToolRequest, args ReadLintsArgs) *mcp.CallToolResult {
+	if args.Workspace != "" {
+		return mcp.NewToolResultError("workspace and workspaces are mutually exclusive")
+	}
+	if args.ExportPath != "" {
+		return mcp.NewToolResultError("exportPath is not supported with workspaces, each workspace would overwrite it")
+	}
+	var workspaces []string
+	for _, ws := range args.Workspaces {
+		if !slices.Contains(workspaces, ws) {
+			workspaces = append(workspaces, ws)
+		}
+	}
+	logger.Infof("collecting diagnostics of %d workspaces", len(workspaces))
+
+	progress := sharedProgress(progressReporter(ctx, req))
+	results := make([]workspaceResult, len(workspaces))
+	slots := make(chan struct{}, MaxParallelWorkspaces)
+	var wg sync.WaitGroup
+	for i, ws := range workspaces {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = readLintsWorkspace(ctx, args, ws, progress)
+		}()
+	}
+	wg.Wait()
+
+	gateFailed := false
+	for _, r := range results {
+		gateFailed = gateFailed || r.GateFailed
+	}
+	var output string
+	if jsonOutput(args.OutputFormat) {
+		data, err := json.MarshalIndent(map[string]any{"workspaces": results}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to format diagnostics", err)
+		}
+		output = string(data)
+	} else {
+		sections := make([]string, 0, len(results))
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				sections = append(sections, fmt.Sprintf("== workspace %s (failed) ==\n%s", r.Workspace, r.Error))
+			case r.output == "":
+				sections = append(sections, fmt.Sprintf("== workspace %s ==", r.Workspace))
+			default:
+				sections = append(sections, fmt.Sprintf("== workspace %s ==\n%s", r.Workspace, r.output))
+			}
+		}
+		output = strings.Join(sections, "\n\n")
+	}
+	if gateFailed {
+		return mcp.NewToolResultError(output)
+	}
+	return mcp.NewToolResultText(output)
+}
+
+// readLintsWorkspace runs read-lints for workspace with the options of args,
+// reporting its progress through progress.
+func readLintsWorkspace(ctx context.Context, args ReadLintsArgs, workspace string, progress func(workspace string) nvim.ProgressFunc) workspaceResult {
+	args.Workspace = repoRoot(ctx, workspace, args.UseRepoRoot)
+	args.Workspaces = nil
+	result := workspaceResult{Workspace: args.Workspace}
+	output, gateFailed, errResult := readLints(ctx, args, progress(args.Workspace))
+	if errResult != nil {
+		result.Error = resultText(errResult)
+		logger.Warnf("read-lints failed for workspace %s: %s", args.Workspace, result.Error)
+		return result
+	}
+	result.GateFailed = gateFailed
+	result.output = output
+	if jsonOutput(args.OutputFormat) && json.Valid([]byte(output)) {
+		result.Report = json.RawMessage(output)
+	}
+	return result
+}
+
+// jsonOutput reports whether format renders JSON, to embed per workspace.
+func jsonOutput(format string) bool {
+	return format == nvim.OutputJSON || format == nvim.OutputLSP
+}
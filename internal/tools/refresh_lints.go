@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/leonardcser/nvim-lsp-mcp/internal/nvim"
+)
+
+// RefreshLintsArgs defines the structured input schema for the refresh-lints tool.
+type RefreshLintsArgs struct {
+	Workspace          string   `json:"workspace,omitempty" jsonschema_description:"Absolute workspace path. Defaults to NVIM_LSP_MCP_DEFAULT_WORKSPACE, or to the cwd of the only running Neovim session, when omitted."`
+	UseRepoRoot        bool     `json:"useRepoRoot,omitempty" jsonschema_description:"Resolve workspace to the top level of the git repository containing it (git rev-parse --show-toplevel) before connecting, for when a subdirectory was passed. Falls back to workspace outside a git repository."`
+	Files              []string `json:"files,omitempty" jsonschema_description:"List of absolute file paths (or file:// URIs) to refresh, if empty, fallsback to refreshing changed files (staged and unstaged) via git diff."`
+	Force              bool     `json:"force,omitempty" jsonschema_description:"Reload buffers from disk even if they have unsaved changes"`
+	RefreshClients     []string `json:"refreshClients,omitempty" jsonschema_description:"Only notify these LSP clients (by name, e.g. gopls) of the refresh, so only the relevant servers re-check the files. Empty means all clients."`
+	ReloadWhileEditing bool     `json:"reloadWhileEditing,omitempty" jsonschema_description:"Reload the buffer the user is currently editing in insert, replace, visual or select mode too. By default it is left alone, with a warning, so the refresh does not disturb their typing."`
+	ReloadBuffers      *bool    `json:"reloadBuffers,omitempty" jsonschema_description:"Load and reload buffers from disk before notifying LSP clients. Set to false when buffers are kept in sync with disk (e.g. autoread) to only send didSave for already loaded buffers. Defaults to true."`
+}
+
+// RefreshLintsHandler returns the MCP tool handler for the "refresh-lints" tool.
+func RefreshLintsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args RefreshLintsArgs
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	workspace, errResult := resolveWorkspace(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	args.Workspace = repoRoot(ctx, workspace, args.UseRepoRoot)
+
+	cli, errResult := attach(ctx, args.Workspace)
+	if errResult != nil {
+		return errResult, nil
+	}
+	defer cli.Close()
+	cli.SetProgress(progressReporter(ctx, req))
+
+	result, err := nvim.RefreshDiagnostics(ctx, cli, args.Files, args.Force, args.ReloadBuffers == nil || *args.ReloadBuffers, args.RefreshClients, !args.ReloadWhileEditing)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to refresh diagnostics", err), nil
+	}
+
+	var lines []string
+	if len(result.Refreshed) == 0 && len(result.SkippedUnsaved) == 0 {
+		lines = append(lines, "no files to refresh")
+	} else {
+		lines = append(lines, "refreshed:")
+		for _, f := range result.Refreshed {
+			lines = append(lines, "  "+f)
+		}
+	}
+	if len(result.SkippedUnsaved) > 0 {
+		lines = append(lines, "", "skipped reloading (unsaved changes):")
+		for _, f := range result.SkippedUnsaved {
+			lines = append(lines, "  "+f)
+		}
+	}
+	if len(result.Warnings) > 0 {
+		lines = append(lines, "", "--- warnings ---")
+		for _, w := range result.Warnings {
+			lines = append(lines, "  "+w)
+		}
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}